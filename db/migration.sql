@@ -1,20 +1,31 @@
+-- Needed for the reservations exclusion constraint, which mixes = on a uuid
+-- with && on a daterange.
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
 CREATE TABLE users (
     id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
     full_name TEXT NOT NULL,
     username TEXT NOT NULL UNIQUE,
     email TEXT NOT NULL UNIQUE,
     password TEXT NOT NULL,
-    avatar_key TEXT DEFAULT ''
+    avatar_key TEXT DEFAULT '',
+    role TEXT NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'admin')),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
 );
 
 CREATE TABLE messages (
     id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
     room_id TEXT NOT NULL,
+    type TEXT NOT NULL DEFAULT 'text' CHECK (type IN (
+        'text', 'system', 'offer_submitted', 'offer_countered', 'offer_accepted',
+        'offer_rejected', 'offer_withdrawn', 'offer_expired'
+    )),
     message TEXT NOT NULL,
     sender_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
     sender_name TEXT NOT NULL,
-    created_at TIMESTAMP DEFAULT NOW(),
-    read_at TIMESTAMP NULL
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    read_at TIMESTAMP NULL,
+    offer_id UUID NULL
 );
 
 CREATE TABLE rooms (
@@ -39,7 +50,7 @@ CREATE TABLE listings (
     location TEXT NOT NULL,
     bathrooms INTEGER NOT NULL CHECK (bathrooms >= 0) DEFAULT 0,
     bedrooms INTEGER NOT NULL CHECK (bedrooms >= 0) DEFAULT 0,
-    created_at TIMESTAMP DEFAULT NOW(),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
     image_keys TEXT[] NOT NULL,
     is_air_conditioned BOOLEAN NOT NULL DEFAULT FALSE,
     is_balcony_available BOOLEAN NOT NULL DEFAULT FALSE,
@@ -49,12 +60,278 @@ CREATE TABLE listings (
     is_pool_available BOOLEAN NOT NULL DEFAULT FALSE,
     is_washer_available BOOLEAN NOT NULL DEFAULT FALSE,
     is_wifi_available BOOLEAN NOT NULL DEFAULT FALSE,
-    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    latitude DOUBLE PRECISION NULL CHECK (latitude BETWEEN -90 AND 90),
+    longitude DOUBLE PRECISION NULL CHECK (longitude BETWEEN -180 AND 180),
+    version INTEGER NOT NULL DEFAULT 1,
+    status TEXT NOT NULL DEFAULT 'published'
+        CHECK (status IN ('draft', 'published', 'under_offer', 'sold', 'rented', 'archived')),
+    previous_price INTEGER NULL,
+    price_dropped_at TIMESTAMP NULL,
+    -- When the listing last went from hidden to public. Saved search alerts
+    -- match on this, so drafts published later are still reported.
+    published_at TIMESTAMP NULL,
+    search_vector TSVECTOR GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+        setweight(to_tsvector('english', coalesce(location, '')), 'B') ||
+        setweight(to_tsvector('english', coalesce(description, '')), 'C')
+    ) STORED
 );
 
+CREATE TABLE bookmark_collections (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    owner_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    -- NULL while the collection has no share link.
+    share_nonce TEXT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (owner_id, name)
+);
+
+CREATE TABLE bookmark_collection_members (
+    collection_id UUID NOT NULL REFERENCES bookmark_collections(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (collection_id, user_id)
+);
+
+-- Rows with a NULL collection_id are the user's plain bookmarks; the others
+-- are collection items, where user_id is whoever added the listing.
 CREATE TABLE bookmarks (
     id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
     user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
     listing_id UUID NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
-    UNIQUE (user_id, listing_id)
-);
\ No newline at end of file
+    collection_id UUID NULL REFERENCES bookmark_collections(id) ON DELETE CASCADE,
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    notify BOOLEAN NOT NULL DEFAULT TRUE
+);
+
+CREATE TABLE listing_price_history (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    listing_id UUID NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+    old_price INTEGER NOT NULL,
+    new_price INTEGER NOT NULL,
+    changed_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE notifications (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type TEXT NOT NULL,
+    listing_id UUID NULL REFERENCES listings(id) ON DELETE SET NULL,
+    title TEXT NOT NULL,
+    body TEXT NOT NULL,
+    read_at TIMESTAMP NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE saved_searches (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    filter JSONB NOT NULL DEFAULT '{}',
+    frequency TEXT NOT NULL DEFAULT 'instant' CHECK (frequency IN ('instant', 'daily')),
+    last_checked_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE viewing_slots (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    listing_id UUID NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+    starts_at TIMESTAMPTZ NOT NULL,
+    ends_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (ends_at > starts_at),
+    UNIQUE (listing_id, starts_at)
+);
+
+-- A booked viewing is never reported as completed here; that is derived from
+-- the slot's end time when reading.
+CREATE TABLE viewings (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    slot_id UUID NOT NULL REFERENCES viewing_slots(id) ON DELETE CASCADE,
+    listing_id UUID NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+    customer_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    owner_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    room_id UUID NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'booked' CHECK (status IN ('booked', 'cancelled')),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE listing_rental_settings (
+    listing_id UUID PRIMARY KEY REFERENCES listings(id) ON DELETE CASCADE,
+    period TEXT NOT NULL DEFAULT 'nightly' CHECK (period IN ('nightly', 'monthly'))
+);
+
+-- Owner overrides for single nights; nights without a row are open at the
+-- listing price.
+CREATE TABLE listing_calendar (
+    listing_id UUID NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    is_blocked BOOLEAN NOT NULL DEFAULT FALSE,
+    price INTEGER NULL CHECK (price > 0),
+    PRIMARY KEY (listing_id, date)
+);
+
+CREATE TABLE reservations (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    listing_id UUID NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+    customer_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    owner_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    check_in DATE NOT NULL,
+    check_out DATE NOT NULL,
+    total_price INTEGER NOT NULL CHECK (total_price > 0),
+    status TEXT NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'confirmed', 'declined', 'cancelled')),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (check_out > check_in),
+    EXCLUDE USING gist (listing_id WITH =, daterange(check_in, check_out) WITH &&)
+        WHERE (status = 'confirmed')
+);
+
+-- amount, conditions and expires_at are the terms on the table, proposed by
+-- whoever is not awaiting_user_id.
+CREATE TABLE offers (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    listing_id UUID NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+    room_id UUID NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    customer_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    owner_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    amount INTEGER NOT NULL CHECK (amount > 0),
+    conditions TEXT NOT NULL DEFAULT '',
+    expires_at TIMESTAMPTZ NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'accepted', 'rejected', 'withdrawn', 'expired')),
+    awaiting_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE offer_events (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    offer_id UUID NOT NULL REFERENCES offers(id) ON DELETE CASCADE,
+    actor_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type TEXT NOT NULL CHECK (type IN (
+        'offer_submitted', 'offer_countered', 'offer_accepted',
+        'offer_rejected', 'offer_withdrawn', 'offer_expired'
+    )),
+    amount INTEGER NULL,
+    conditions TEXT NULL,
+    expires_at TIMESTAMPTZ NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Reviews outlive the listing and room they were left from, so owners can't
+-- delete their way out of a bad rating.
+CREATE TABLE reviews (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    room_id UUID NULL UNIQUE REFERENCES rooms(id) ON DELETE SET NULL,
+    listing_id UUID NULL REFERENCES listings(id) ON DELETE SET NULL,
+    owner_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    author_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    rating INTEGER NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    text TEXT NOT NULL,
+    reply TEXT NULL,
+    replied_at TIMESTAMP NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Chat frames too large for a NOTIFY payload, kept just long enough for the
+-- other instances to read them.
+CREATE TABLE broker_payloads (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    payload TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- IDs of WebSocket tickets that have been used, kept until they expire so
+-- each ticket opens only one connection.
+CREATE TABLE ws_ticket_uses (
+    id TEXT PRIMARY KEY,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+-- One subscription feed per user. Only a SHA-256 hash of the token is kept.
+CREATE TABLE calendar_feed_tokens (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE amenities (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    icon TEXT NOT NULL DEFAULT '',
+    category TEXT NOT NULL,
+    -- Set for the amenities that mirror a listings.is_* column.
+    legacy_column TEXT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE listing_amenities (
+    listing_id UUID NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+    amenity_id UUID NOT NULL REFERENCES amenities(id) ON DELETE CASCADE,
+    PRIMARY KEY (listing_id, amenity_id)
+);
+
+INSERT INTO amenities (name, icon, category, legacy_column) VALUES
+    ('Air conditioning', 'snowflake', 'Climate', 'is_air_conditioned'),
+    ('Heating', 'flame', 'Climate', 'is_heated'),
+    ('Balcony', 'balcony', 'Outdoor', 'is_balcony_available'),
+    ('Pool', 'pool', 'Outdoor', 'is_pool_available'),
+    ('Parking', 'car', 'Parking', 'is_parking_available'),
+    ('Washer', 'washer', 'Laundry', 'is_washer_available'),
+    ('Dryer', 'dryer', 'Laundry', 'is_dryer_available'),
+    ('Wi-Fi', 'wifi', 'Internet', 'is_wifi_available');
+
+-- Backfill for listings created before the amenities catalog existed.
+INSERT INTO listing_amenities (listing_id, amenity_id)
+SELECT l.id, a.id FROM listings l
+JOIN amenities a ON CASE a.legacy_column
+    WHEN 'is_air_conditioned' THEN l.is_air_conditioned
+    WHEN 'is_balcony_available' THEN l.is_balcony_available
+    WHEN 'is_dryer_available' THEN l.is_dryer_available
+    WHEN 'is_heated' THEN l.is_heated
+    WHEN 'is_parking_available' THEN l.is_parking_available
+    WHEN 'is_pool_available' THEN l.is_pool_available
+    WHEN 'is_washer_available' THEN l.is_washer_available
+    WHEN 'is_wifi_available' THEN l.is_wifi_available
+    ELSE FALSE
+END;
+
+CREATE INDEX idx_listings_type ON listings (type);
+CREATE INDEX idx_listings_status ON listings (status);
+CREATE INDEX idx_listings_user_id ON listings (user_id);
+CREATE INDEX idx_listings_price ON listings (price);
+CREATE INDEX idx_listings_lat_lng ON listings (latitude, longitude) WHERE latitude IS NOT NULL;
+CREATE INDEX idx_listings_search_vector ON listings USING GIN (search_vector);
+CREATE INDEX idx_listings_created_at_id ON listings (created_at DESC, id DESC);
+CREATE INDEX idx_listings_published_at ON listings (published_at);
+CREATE INDEX idx_messages_room_created_at_id ON messages (room_id, created_at DESC, id DESC);
+CREATE INDEX idx_listing_price_history_listing_id ON listing_price_history (listing_id, changed_at DESC);
+CREATE INDEX idx_listing_amenities_amenity_id ON listing_amenities (amenity_id);
+CREATE INDEX idx_bookmarks_user_created_at_id ON bookmarks (user_id, created_at DESC, id DESC);
+CREATE INDEX idx_bookmarks_listing_id ON bookmarks (listing_id) WHERE collection_id IS NULL;
+CREATE INDEX idx_notifications_user_created_at_id ON notifications (user_id, created_at DESC, id DESC);
+CREATE INDEX idx_saved_searches_user_id ON saved_searches (user_id);
+CREATE UNIQUE INDEX idx_bookmarks_user_listing ON bookmarks (user_id, listing_id) WHERE collection_id IS NULL;
+CREATE UNIQUE INDEX idx_bookmarks_collection_listing ON bookmarks (collection_id, listing_id) WHERE collection_id IS NOT NULL;
+CREATE INDEX idx_bookmark_collection_members_user_id ON bookmark_collection_members (user_id);
+CREATE INDEX idx_viewings_customer_id ON viewings (customer_id) WHERE status = 'booked';
+CREATE INDEX idx_viewings_owner_id ON viewings (owner_id) WHERE status = 'booked';
+-- Prevents double booking: a slot holds at most one active viewing.
+CREATE UNIQUE INDEX idx_viewings_slot_booked ON viewings (slot_id) WHERE status = 'booked';
+CREATE INDEX idx_listing_calendar_blocked ON listing_calendar (listing_id, date) WHERE is_blocked;
+CREATE INDEX idx_reservations_customer_id ON reservations (customer_id);
+CREATE INDEX idx_reservations_owner_id ON reservations (owner_id);
+-- A customer negotiates one offer per listing at a time.
+CREATE UNIQUE INDEX idx_offers_listing_customer_pending ON offers (listing_id, customer_id) WHERE status = 'pending';
+CREATE INDEX idx_offers_expires_at ON offers (expires_at) WHERE status = 'pending';
+CREATE INDEX idx_offers_customer_id ON offers (customer_id);
+CREATE INDEX idx_offers_owner_id ON offers (owner_id);
+CREATE INDEX idx_offer_events_offer_id ON offer_events (offer_id, created_at);
+CREATE INDEX idx_reviews_owner_id ON reviews (owner_id, created_at DESC);
+CREATE INDEX idx_reviews_listing_id ON reviews (listing_id, created_at DESC);
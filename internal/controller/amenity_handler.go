@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"message-server/internal/domain"
+	"message-server/internal/usecases"
+	"message-server/pkg"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AmenityHandler struct {
+	amenityUseCase *usecases.AmenityUseCase
+}
+
+func NewAmenityHandler(amenityUseCase *usecases.AmenityUseCase) *AmenityHandler {
+	return &AmenityHandler{amenityUseCase: amenityUseCase}
+}
+
+func (s *AmenityHandler) GetAmenities(c *gin.Context) {
+	amenities, err := s.amenityUseCase.GetAmenities()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get amenities"})
+		return
+	}
+
+	c.JSON(http.StatusOK, amenities)
+}
+
+func (s *AmenityHandler) CreateAmenity(c *gin.Context) {
+	var request domain.AmenityRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	if errors := pkg.ValidateStruct(request); len(errors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errors})
+		return
+	}
+
+	id, err := s.amenityUseCase.CreateAmenity(&request)
+	if err != nil {
+		writeAmenityError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+func (s *AmenityHandler) UpdateAmenity(c *gin.Context) {
+	var request domain.AmenityRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	if errors := pkg.ValidateStruct(request); len(errors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errors})
+		return
+	}
+
+	if err := s.amenityUseCase.UpdateAmenity(c.Param("id"), &request); err != nil {
+		writeAmenityError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Amenity updated successfully"})
+}
+
+func (s *AmenityHandler) DeleteAmenity(c *gin.Context) {
+	if err := s.amenityUseCase.DeleteAmenity(c.Param("id")); err != nil {
+		writeAmenityError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Amenity deleted successfully"})
+}
+
+func writeAmenityError(c *gin.Context, err error) {
+	switch err {
+	case domain.ErrAmenityNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case domain.ErrDuplicateAmenity:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "field": "name"})
+	case domain.ErrLegacyAmenity:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save amenity"})
+	}
+}
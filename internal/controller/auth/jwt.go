@@ -3,25 +3,37 @@ package auth
 import (
 	"errors"
 	"os"
+	"slices"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 var jwtSecret = []byte(os.Getenv("JWT_SECRET"))
 
+// WSTicketTTL is how long a WebSocket ticket can be used to open a
+// connection. It is only checked at upgrade time, and each ticket opens one
+// connection.
+const WSTicketTTL = 30 * time.Second
+
+// wsTicketAudience marks tickets so they can't be used as session tokens.
+const wsTicketAudience = "ws"
+
 type Claims struct {
 	Username string `json:"username"`
 	UserID   string `json:"user_id"`
 	Email    string `json:"email"`
+	Role     string `json:"role,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func GenerateToken(username, email, userID string) (string, error) {
+func GenerateToken(username, email, userID, role string) (string, error) {
 	claims := Claims{
 		Username: username,
 		UserID:   userID,
 		Email:    email,
+		Role:     role,
 		RegisteredClaims: jwt.RegisteredClaims{
 			Issuer:    "staybook",
 			Subject:   userID,
@@ -44,8 +56,48 @@ func ValidateToken(tokenString string) (*Claims, error) {
 	}
 
 	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
+		if slices.Contains(claims.Audience, wsTicketAudience) {
+			return nil, errors.New("invalid token")
+		}
 		return claims, nil
 	}
 
 	return nil, errors.New("invalid token")
 }
+
+// GenerateWSTicket issues a short-lived token for clients that can't send the
+// auth cookie with the WebSocket upgrade. Its ID is recorded when it is used
+// so it can't be replayed.
+func GenerateWSTicket(userID string) (string, error) {
+	now := time.Now()
+	claims := Claims{
+		UserID: userID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "staybook",
+			Subject:   userID,
+			ID:        uuid.NewString(),
+			Audience:  jwt.ClaimStrings{wsTicketAudience},
+			ExpiresAt: jwt.NewNumericDate(now.Add(WSTicketTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(jwtSecret)
+}
+
+func ValidateWSTicket(ticket string) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(ticket, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+		return jwtSecret, nil
+	}, jwt.WithAudience(wsTicketAudience))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := token.Claims.(*Claims); ok && token.Valid && claims.ID != "" && claims.ExpiresAt != nil {
+		return claims, nil
+	}
+
+	return nil, errors.New("invalid ticket")
+}
@@ -26,3 +26,31 @@ func JWTAuthMiddleware() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// OptionalJWTAuthMiddleware sets the claims when a valid auth cookie is
+// present and otherwise lets the request through anonymously.
+func OptionalJWTAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token, err := c.Cookie("auth_token"); err == nil {
+			if claims, err := ValidateToken(token); err == nil {
+				c.Set("claims", claims)
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// RequireRole must run after JWTAuthMiddleware.
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, exists := c.Get("claims")
+		if !exists || claims.(*Claims).Role != role {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
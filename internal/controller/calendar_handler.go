@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"message-server/internal/controller/auth"
+	"message-server/internal/domain"
+	"message-server/internal/usecases"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const calendarContentType = "text/calendar; charset=utf-8"
+
+type CalendarHandler struct {
+	calendarUseCase *usecases.CalendarUseCase
+}
+
+func NewCalendarHandler(calendarUseCase *usecases.CalendarUseCase) *CalendarHandler {
+	return &CalendarHandler{calendarUseCase: calendarUseCase}
+}
+
+func (s *CalendarHandler) GetViewingCalendar(c *gin.Context) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	id := c.Param("id")
+	calendar, err := s.calendarUseCase.GetViewingCalendar(claims.(*auth.Claims).UserID, id)
+	if err != nil {
+		writeViewingError(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="viewing-`+id+`.ics"`)
+	c.Data(http.StatusOK, calendarContentType, calendar)
+}
+
+func (s *CalendarHandler) CreateFeedToken(c *gin.Context) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	token, err := s.calendarUseCase.CreateFeedToken(claims.(*auth.Claims).UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, domain.CalendarFeedResponse{
+		Token: token,
+		URL:   requestBaseURL(c) + "/calendar/" + token + ".ics",
+	})
+}
+
+func (s *CalendarHandler) RevokeFeedToken(c *gin.Context) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	err := s.calendarUseCase.RevokeFeedToken(claims.(*auth.Claims).UserID)
+	switch err {
+	case nil:
+		c.JSON(http.StatusOK, gin.H{"message": "Calendar feed revoked"})
+	case domain.ErrCalendarFeedNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// GetFeed is public: calendar apps can't log in, so the token is the
+// credential.
+func (s *CalendarHandler) GetFeed(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+
+	calendar, err := s.calendarUseCase.GetFeed(token)
+	switch err {
+	case nil:
+		c.Data(http.StatusOK, calendarContentType, calendar)
+	case domain.ErrCalendarFeedNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// requestBaseURL is the scheme and host the client used, honouring the
+// proxy headers set by the hosting platform.
+func requestBaseURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+
+	return scheme + "://" + c.Request.Host
+}
@@ -0,0 +1,266 @@
+package controller
+
+import (
+	"message-server/internal/controller/auth"
+	"message-server/internal/domain"
+	"message-server/internal/usecases"
+	"message-server/pkg"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CollectionHandler struct {
+	collectionUseCase *usecases.CollectionUseCase
+}
+
+func NewCollectionHandler(collectionUseCase *usecases.CollectionUseCase) *CollectionHandler {
+	return &CollectionHandler{collectionUseCase: collectionUseCase}
+}
+
+func (s *CollectionHandler) CreateCollection(c *gin.Context) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var request domain.CollectionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	if errors := pkg.ValidateStruct(request); len(errors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errors})
+		return
+	}
+
+	collection, err := s.collectionUseCase.CreateCollection(claims.(*auth.Claims).UserID, &request)
+	if err != nil {
+		writeCollectionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, collection)
+}
+
+func (s *CollectionHandler) GetCollections(c *gin.Context) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	collections, err := s.collectionUseCase.GetCollections(claims.(*auth.Claims).UserID)
+	if err != nil {
+		writeCollectionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, collections)
+}
+
+func (s *CollectionHandler) GetCollection(c *gin.Context) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	collection, err := s.collectionUseCase.GetCollection(claims.(*auth.Claims).UserID, c.Param("id"))
+	if err != nil {
+		writeCollectionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, collection)
+}
+
+func (s *CollectionHandler) RenameCollection(c *gin.Context) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var request domain.CollectionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	if errors := pkg.ValidateStruct(request); len(errors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errors})
+		return
+	}
+
+	collection, err := s.collectionUseCase.RenameCollection(claims.(*auth.Claims).UserID, c.Param("id"), &request)
+	if err != nil {
+		writeCollectionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, collection)
+}
+
+func (s *CollectionHandler) DeleteCollection(c *gin.Context) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	if err := s.collectionUseCase.DeleteCollection(claims.(*auth.Claims).UserID, c.Param("id")); err != nil {
+		writeCollectionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Collection deleted successfully"})
+}
+
+func (s *CollectionHandler) SaveCollectionItem(c *gin.Context) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var request domain.CollectionItemRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+			return
+		}
+	}
+
+	if errors := pkg.ValidateStruct(request); len(errors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errors})
+		return
+	}
+
+	userID := claims.(*auth.Claims).UserID
+	err := s.collectionUseCase.SaveCollectionItem(userID, c.Param("id"), c.Param("listing_id"), &request)
+	if err != nil {
+		writeCollectionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Listing saved to collection"})
+}
+
+func (s *CollectionHandler) RemoveCollectionItem(c *gin.Context) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	userID := claims.(*auth.Claims).UserID
+	if err := s.collectionUseCase.RemoveCollectionItem(userID, c.Param("id"), c.Param("listing_id")); err != nil {
+		writeCollectionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Listing removed from collection"})
+}
+
+func (s *CollectionHandler) AddCollaborator(c *gin.Context) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var request domain.CollaboratorRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	if errors := pkg.ValidateStruct(request); len(errors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errors})
+		return
+	}
+
+	collaborators, err := s.collectionUseCase.AddCollaborator(claims.(*auth.Claims).UserID, c.Param("id"), &request)
+	if err != nil {
+		writeCollectionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, collaborators)
+}
+
+func (s *CollectionHandler) RemoveCollaborator(c *gin.Context) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	userID := claims.(*auth.Claims).UserID
+	if err := s.collectionUseCase.RemoveCollaborator(userID, c.Param("id"), c.Param("user_id")); err != nil {
+		writeCollectionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Collaborator removed successfully"})
+}
+
+func (s *CollectionHandler) ShareCollection(c *gin.Context) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	share, err := s.collectionUseCase.ShareCollection(claims.(*auth.Claims).UserID, c.Param("id"))
+	if err != nil {
+		writeCollectionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, share)
+}
+
+func (s *CollectionHandler) UnshareCollection(c *gin.Context) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	if err := s.collectionUseCase.UnshareCollection(claims.(*auth.Claims).UserID, c.Param("id")); err != nil {
+		writeCollectionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Share link revoked"})
+}
+
+func (s *CollectionHandler) GetSharedCollection(c *gin.Context) {
+	collection, err := s.collectionUseCase.GetSharedCollection(c.Param("token"), viewerID(c))
+	if err != nil {
+		writeCollectionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, collection)
+}
+
+func writeCollectionError(c *gin.Context, err error) {
+	switch err {
+	case domain.ErrCollectionNotFound, domain.ErrCollectionItemNotFound, domain.ErrCollaboratorNotFound,
+		domain.ErrListingNotFound, domain.ErrUserNotFound, domain.ErrInvalidShareToken:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case domain.ErrForbidden:
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the collection owner can do this"})
+	case domain.ErrDuplicateCollection:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case domain.ErrCollaboratorIsOwner:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,306 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"message-server/internal/domain"
+	"message-server/pkg"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// sendBufferSize is how many frames may wait for a connection's writer
+	// before the connection is dropped as too slow.
+	sendBufferSize = 256
+	// receiptTTL is how long a receipt ID is remembered. Copies from other
+	// instances arrive well within it.
+	receiptTTL = time.Minute
+)
+
+// wsMetrics is published under "websocket" in /admin/debug/vars.
+var wsMetrics = expvar.NewMap("websocket")
+
+// wsClient is one live WebSocket connection. A user has one per device.
+// Only its writer goroutine writes to conn.
+type wsClient struct {
+	id          string
+	userID      string
+	conn        *websocket.Conn
+	userAgent   string
+	connectedAt time.Time
+	send        chan any
+	closed      chan struct{}
+	closeOnce   sync.Once
+}
+
+func newWSClient(id, userID, userAgent string, conn *websocket.Conn) *wsClient {
+	return &wsClient{
+		id:          id,
+		userID:      userID,
+		conn:        conn,
+		userAgent:   userAgent,
+		connectedAt: time.Now(),
+		send:        make(chan any, sendBufferSize),
+		closed:      make(chan struct{}),
+	}
+}
+
+// enqueue hands a frame to the connection's writer without blocking. A
+// client whose buffer is full is closed rather than allowed to hold up the
+// sender.
+func (c *wsClient) enqueue(message any) bool {
+	select {
+	case <-c.closed:
+		return false
+	default:
+	}
+
+	select {
+	case c.send <- message:
+		return true
+	default:
+		wsMetrics.Add("dropped_messages", 1)
+		wsMetrics.Add("dropped_slow_connections", 1)
+		c.close()
+		return false
+	}
+}
+
+// close stops the writer, which closes the connection; the reader then fails
+// and returns.
+func (c *wsClient) close() {
+	c.closeOnce.Do(func() { close(c.closed) })
+}
+
+// writePump is the only goroutine that writes to the connection. It sends
+// queued frames and pings until the client is closed or a write fails.
+func (c *wsClient) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.close()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+		case <-c.closed:
+			return
+		case message := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteJSON(message); err != nil {
+				pkg.Logger.Printf("Error writing to WebSocket %s: %v", c.id, err)
+				return
+			}
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				pkg.Logger.Printf("Ping failed, terminating connection: %v", err)
+				return
+			}
+		}
+	}
+}
+
+// Hub tracks the live connections on this instance, grouped by user, and
+// exchanges frames with the other instances through the broker.
+type Hub struct {
+	mutex      sync.RWMutex
+	clients    map[string]map[string]*wsClient
+	instanceID string
+	broker     domain.MessageBroker
+
+	receiptMutex sync.Mutex
+	receipts     map[string]time.Time
+}
+
+func newHub(broker domain.MessageBroker) *Hub {
+	return &Hub{
+		clients:    make(map[string]map[string]*wsClient),
+		instanceID: uuid.NewString(),
+		broker:     broker,
+		receipts:   make(map[string]time.Time),
+	}
+}
+
+// start subscribes to frames published by the other instances.
+func (h *Hub) start(ctx context.Context) error {
+	return h.broker.Subscribe(ctx, h.receive)
+}
+
+// send delivers the frame to the user's devices on every instance, except
+// skip, and returns how many devices on this instance accepted it.
+func (h *Hub) send(userID string, frame any, skip *wsClient) int {
+	delivered := h.deliver(userID, frame, skip)
+	h.publish(userID, frame, nil)
+	return delivered
+}
+
+// publish hands the frame to the other instances. An instance that delivers
+// it sends receipt on, if given; the receiving user sees it once however many
+// instances deliver the frame.
+func (h *Hub) publish(userID string, frame any, receipt *domain.BrokerMessage) {
+	payload, err := json.Marshal(frame)
+	if err != nil {
+		pkg.Logger.Printf("Failed to encode frame for %s: %v", userID, err)
+		return
+	}
+
+	if receipt != nil {
+		receipt = &domain.BrokerMessage{ID: uuid.NewString(), UserID: receipt.UserID, Frame: receipt.Frame}
+	}
+
+	message := &domain.BrokerMessage{Origin: h.instanceID, UserID: userID, Frame: payload, Receipt: receipt}
+	h.broadcast(message)
+}
+
+func (h *Hub) broadcast(message *domain.BrokerMessage) {
+	if err := h.broker.Publish(context.Background(), message); err != nil {
+		pkg.Logger.Printf("Failed to publish frame for %s: %v", message.UserID, err)
+	}
+}
+
+func (h *Hub) receive(message *domain.BrokerMessage) {
+	if message.Origin == h.instanceID {
+		return
+	}
+	if message.ID != "" && !h.claimReceipt(message.ID) {
+		return
+	}
+
+	if h.deliver(message.UserID, message.Frame, nil) > 0 && message.Receipt != nil {
+		h.sendReceipt(message.Receipt)
+	}
+}
+
+// sendReceipt delivers a receipt for a frame this instance delivered, unless
+// a copy from another delivering instance got here first.
+func (h *Hub) sendReceipt(receipt *domain.BrokerMessage) {
+	if !h.claimReceipt(receipt.ID) {
+		return
+	}
+
+	h.deliver(receipt.UserID, receipt.Frame, nil)
+	h.broadcast(&domain.BrokerMessage{ID: receipt.ID, Origin: h.instanceID, UserID: receipt.UserID, Frame: receipt.Frame})
+}
+
+// claimReceipt reports whether the receipt ID is new to this instance.
+func (h *Hub) claimReceipt(id string) bool {
+	h.receiptMutex.Lock()
+	defer h.receiptMutex.Unlock()
+
+	now := time.Now()
+	for seen, at := range h.receipts {
+		if now.Sub(at) > receiptTTL {
+			delete(h.receipts, seen)
+		}
+	}
+
+	if _, seen := h.receipts[id]; seen {
+		return false
+	}
+	h.receipts[id] = now
+	return true
+}
+
+// deliver queues the frame on the user's connections to this instance,
+// except skip, and returns how many accepted it.
+func (h *Hub) deliver(userID string, frame any, skip *wsClient) int {
+	delivered := 0
+	for _, client := range h.connections(userID) {
+		if client != skip && client.enqueue(frame) {
+			delivered++
+		}
+	}
+	return delivered
+}
+
+func (h *Hub) register(client *wsClient) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.clients[client.userID] == nil {
+		h.clients[client.userID] = make(map[string]*wsClient)
+	}
+	h.clients[client.userID][client.id] = client
+}
+
+// unregister reports whether the client was still registered, so the
+// connection is only torn down once.
+func (h *Hub) unregister(client *wsClient) bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	devices := h.clients[client.userID]
+	if devices[client.id] != client {
+		return false
+	}
+
+	delete(devices, client.id)
+	if len(devices) == 0 {
+		delete(h.clients, client.userID)
+	}
+	return true
+}
+
+// connections returns a snapshot of the user's connections, so callers can
+// write to them without holding the lock.
+func (h *Hub) connections(userID string) []*wsClient {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	clients := make([]*wsClient, 0, len(h.clients[userID]))
+	for _, client := range h.clients[userID] {
+		clients = append(clients, client)
+	}
+	return clients
+}
+
+// queueDepth is the number of frames waiting across all connections.
+func (h *Hub) queueDepth() any {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	depth := 0
+	for _, devices := range h.clients {
+		for _, client := range devices {
+			depth += len(client.send)
+		}
+	}
+	return depth
+}
+
+func (h *Hub) connectionCount() any {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	count := 0
+	for _, devices := range h.clients {
+		count += len(devices)
+	}
+	return count
+}
+
+// sessions lists the user's live connections, oldest first.
+func (h *Hub) sessions(userID string) []domain.WSSession {
+	clients := h.connections(userID)
+	sort.Slice(clients, func(i, j int) bool {
+		return clients[i].connectedAt.Before(clients[j].connectedAt)
+	})
+
+	sessions := make([]domain.WSSession, 0, len(clients))
+	for _, client := range clients {
+		sessions = append(sessions, domain.WSSession{
+			ID:          client.id,
+			UserAgent:   client.userAgent,
+			ConnectedAt: client.connectedAt,
+		})
+	}
+	return sessions
+}
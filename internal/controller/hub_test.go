@@ -0,0 +1,180 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"message-server/internal/domain"
+	"message-server/internal/repository"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// The Postgres cases run only when TEST_DB_URL points at a database with
+// db/migration.sql applied.
+func testBrokers() map[string]func(t *testing.T) domain.MessageBroker {
+	return map[string]func(t *testing.T) domain.MessageBroker{
+		"memory": func(t *testing.T) domain.MessageBroker {
+			return repository.NewMemoryBroker()
+		},
+		"postgres": func(t *testing.T) domain.MessageBroker {
+			dbURL := os.Getenv("TEST_DB_URL")
+			if dbURL == "" {
+				t.Skip("TEST_DB_URL not set")
+			}
+
+			pool, err := pgxpool.New(context.Background(), dbURL)
+			if err != nil {
+				t.Fatalf("pgxpool.New() error = %v", err)
+			}
+			t.Cleanup(pool.Close)
+			return repository.NewPostgresBroker(pool)
+		},
+	}
+}
+
+func newTestHub(t *testing.T, broker domain.MessageBroker) *Hub {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	hub := newHub(broker)
+	if err := hub.start(ctx); err != nil {
+		t.Fatalf("start() error = %v", err)
+	}
+	return hub
+}
+
+func newTestClient(hub *Hub, id, userID string) *wsClient {
+	client := newWSClient(id, userID, "test", nil)
+	hub.register(client)
+	return client
+}
+
+func receiveFrame(t *testing.T, client *wsClient) string {
+	t.Helper()
+	select {
+	case frame := <-client.send:
+		body, err := json.Marshal(frame)
+		if err != nil {
+			t.Fatalf("json.Marshal() error = %v", err)
+		}
+		return string(body)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("%s received nothing", client.id)
+		return ""
+	}
+}
+
+func expectNoFrame(t *testing.T, client *wsClient) {
+	t.Helper()
+	select {
+	case frame := <-client.send:
+		t.Errorf("%s received unexpected frame %v", client.id, frame)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func mustJSON(t *testing.T, value any) string {
+	body, err := json.Marshal(value)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	return string(body)
+}
+
+func TestHub_FansOutAcrossInstances(t *testing.T) {
+	for name, newBroker := range testBrokers() {
+		t.Run(name, func(t *testing.T) {
+			broker := newBroker(t)
+			hubA, hubB := newTestHub(t, broker), newTestHub(t, broker)
+
+			laptop := newTestClient(hubA, "laptop", "alice")
+			phone := newTestClient(hubB, "phone", "alice")
+			bob := newTestClient(hubB, "bob", "bob")
+
+			frame := &domain.MessageResponse{Type: "message", Text: "Is it still available?", SenderID: "alice"}
+			if delivered := hubA.send("bob", frame, nil); delivered != 0 {
+				t.Errorf("send() = %d, want no local deliveries", delivered)
+			}
+			if got := receiveFrame(t, bob); got != mustJSON(t, frame) {
+				t.Errorf("bob received %s, want %s", got, mustJSON(t, frame))
+			}
+
+			if delivered := hubA.send("alice", frame, nil); delivered != 1 {
+				t.Errorf("send() = %d, want the laptop", delivered)
+			}
+			receiveFrame(t, laptop)
+			receiveFrame(t, phone)
+			expectNoFrame(t, laptop)
+		})
+	}
+}
+
+func TestHub_SendsReceiptFromDeliveringInstance(t *testing.T) {
+	for name, newBroker := range testBrokers() {
+		t.Run(name, func(t *testing.T) {
+			broker := newBroker(t)
+			hubA, hubB := newTestHub(t, broker), newTestHub(t, broker)
+
+			alice := newTestClient(hubA, "alice", "alice")
+			newTestClient(hubB, "bob", "bob")
+
+			receipt := &domain.MessageResponse{Type: "status", Status: "delivered", Text: "Hello"}
+			hubA.publish("bob", &domain.MessageResponse{Type: "message", Text: "Hello"},
+				&domain.BrokerMessage{UserID: "alice", Frame: json.RawMessage(mustJSON(t, receipt))})
+			if got := receiveFrame(t, alice); got != mustJSON(t, receipt) {
+				t.Errorf("alice received %s, want %s", got, mustJSON(t, receipt))
+			}
+
+			hubA.publish("carol", &domain.MessageResponse{Type: "message", Text: "Hello"},
+				&domain.BrokerMessage{UserID: "alice", Frame: json.RawMessage(mustJSON(t, receipt))})
+			expectNoFrame(t, alice)
+		})
+	}
+}
+
+func TestHub_SendsOneReceiptWhenSeveralInstancesDeliver(t *testing.T) {
+	for name, newBroker := range testBrokers() {
+		t.Run(name, func(t *testing.T) {
+			broker := newBroker(t)
+			hubA, hubB, hubC := newTestHub(t, broker), newTestHub(t, broker), newTestHub(t, broker)
+
+			alice := newTestClient(hubA, "alice", "alice")
+			aliceTablet := newTestClient(hubC, "alice-tablet", "alice")
+			bobLaptop := newTestClient(hubB, "bob-laptop", "bob")
+			bobPhone := newTestClient(hubC, "bob-phone", "bob")
+
+			receipt := &domain.MessageResponse{Type: "status", Status: "delivered", Text: "Hello"}
+			hubA.publish("bob", &domain.MessageResponse{Type: "message", Text: "Hello"},
+				&domain.BrokerMessage{UserID: "alice", Frame: json.RawMessage(mustJSON(t, receipt))})
+
+			receiveFrame(t, bobLaptop)
+			receiveFrame(t, bobPhone)
+			for _, client := range []*wsClient{alice, aliceTablet} {
+				if got := receiveFrame(t, client); got != mustJSON(t, receipt) {
+					t.Errorf("%s received %s, want %s", client.id, got, mustJSON(t, receipt))
+				}
+				expectNoFrame(t, client)
+			}
+		})
+	}
+}
+
+func TestHub_DeliversFramesLargerThanANotification(t *testing.T) {
+	for name, newBroker := range testBrokers() {
+		t.Run(name, func(t *testing.T) {
+			broker := newBroker(t)
+			hubA, hubB := newTestHub(t, broker), newTestHub(t, broker)
+			bob := newTestClient(hubB, "bob", "bob")
+
+			frame := &domain.MessageResponse{Type: "message", Text: strings.Repeat("\"", 5000)}
+			hubA.send("bob", frame, nil)
+			if got := receiveFrame(t, bob); got != mustJSON(t, frame) {
+				t.Errorf("bob received %d bytes, want %d", len(got), len(mustJSON(t, frame)))
+			}
+		})
+	}
+}
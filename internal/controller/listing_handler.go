@@ -1,10 +1,14 @@
 package controller
 
 import (
+	"errors"
 	"message-server/internal/controller/auth"
 	"message-server/internal/domain"
 	"message-server/internal/usecases"
+	"message-server/pkg"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -33,6 +37,11 @@ func (s *ListingHandler) CreateListing(c *gin.Context) {
 		return
 	}
 
+	if errors := pkg.ValidateStruct(request); len(errors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errors})
+		return
+	}
+
 	listingID, err := s.listingUseCase.CreateListing(&request)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -45,45 +54,223 @@ func (s *ListingHandler) CreateListing(c *gin.Context) {
 func (s *ListingHandler) GetListingByID(c *gin.Context) {
 	id := c.Param("id")
 
-	listing, err := s.listingUseCase.GetListingByID(id)
+	listing, err := s.listingUseCase.GetListingByID(id, viewerActor(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		switch err {
+		case domain.ErrListingNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
+	c.Header("ETag", listingETag(listing.Version))
 	c.JSON(http.StatusOK, listing)
 }
 
+func (s *ListingHandler) GetPriceHistory(c *gin.Context) {
+	history, err := s.listingUseCase.GetPriceHistory(c.Param("id"), viewerActor(c))
+	if err != nil {
+		writeListingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
 func (s *ListingHandler) GetListings(c *gin.Context) {
-	listings, err := s.listingUseCase.GetListings()
+	s.listListings(c, s.listingUseCase.GetListings)
+}
+
+func (s *ListingHandler) GetNearbyListings(c *gin.Context) {
+	s.listListings(c, s.listingUseCase.GetNearbyListings)
+}
+
+func (s *ListingHandler) GetMyListings(c *gin.Context) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	userID := claims.(*auth.Claims).UserID
+	s.listListings(c, func(filter *domain.ListingFilter) (*domain.GetListingsResponse, error) {
+		return s.listingUseCase.GetMyListings(userID, filter)
+	})
+}
+
+func (s *ListingHandler) GetListingClusters(c *gin.Context) {
+	var filter domain.ListingClusterFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters"})
+		return
+	}
+
+	if errors := pkg.ValidateStruct(filter); len(errors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errors})
+		return
+	}
+
+	clusters, err := s.listingUseCase.GetListingClusters(&filter)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		switch err {
+		case domain.ErrInvalidBoundingBox, domain.ErrInvalidDateRange, domain.ErrIncompleteDates:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, clusters)
+}
+
+func (s *ListingHandler) listListings(c *gin.Context, list func(*domain.ListingFilter) (*domain.GetListingsResponse, error)) {
+	var filter domain.ListingFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters"})
+		return
+	}
+
+	if errors := pkg.ValidateStruct(filter); len(errors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errors})
+		return
+	}
+	filter.ViewerID = viewerID(c)
+
+	listings, err := list(&filter)
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidCursor, domain.ErrInvalidBoundingBox, domain.ErrMissingCoordinates, domain.ErrInvalidDateRange,
+			domain.ErrIncompleteDates:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
 	c.JSON(http.StatusOK, listings)
 }
 
+// UpdateListing replaces the whole listing. Like PatchListing it requires the
+// ETag from GET /listing/:id as If-Match.
 func (s *ListingHandler) UpdateListing(c *gin.Context) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
 	var request domain.Listing
 	if err := c.ShouldBindJSON(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := s.listingUseCase.UpdateListing(&request); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if errors := pkg.ValidateStruct(request); len(errors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errors})
+		return
+	}
+
+	expectedVersion, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
+
+	request.ID = c.Param("id")
+	actor := actorFromClaims(claims.(*auth.Claims))
+
+	if err := s.listingUseCase.UpdateListing(actor, &request, &expectedVersion); err != nil {
+		writeListingError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Listing updated successfully"})
 }
 
+// PatchListing applies a partial update. Clients send the ETag from
+// GET /listing/:id as If-Match so that edits based on a stale copy fail with
+// 409 instead of silently overwriting newer changes.
+func (s *ListingHandler) PatchListing(c *gin.Context) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var request domain.UpdateListingRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	if fields := pkg.ValidateFields(request); len(fields) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "fields": fields})
+		return
+	}
+
+	expectedVersion, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
+
+	actor := actorFromClaims(claims.(*auth.Claims))
+
+	listing, err := s.listingUseCase.PatchListing(actor, c.Param("id"), &request, &expectedVersion)
+	if err != nil {
+		writeListingError(c, err)
+		return
+	}
+
+	c.Header("ETag", listingETag(listing.Version))
+	c.JSON(http.StatusOK, listing)
+}
+
+func (s *ListingHandler) PublishListing(c *gin.Context) {
+	s.changeListingStatus(c, s.listingUseCase.PublishListing)
+}
+
+func (s *ListingHandler) ArchiveListing(c *gin.Context) {
+	s.changeListingStatus(c, s.listingUseCase.ArchiveListing)
+}
+
+func (s *ListingHandler) MarkListingSold(c *gin.Context) {
+	s.changeListingStatus(c, s.listingUseCase.MarkListingSold)
+}
+
+func (s *ListingHandler) changeListingStatus(c *gin.Context, change func(*domain.Actor, string) (*domain.GetListingDetailsResponse, error)) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	actor := actorFromClaims(claims.(*auth.Claims))
+
+	listing, err := change(actor, c.Param("id"))
+	if err != nil {
+		writeListingError(c, err)
+		return
+	}
+
+	c.Header("ETag", listingETag(listing.Version))
+	c.JSON(http.StatusOK, listing)
+}
+
 func (s *ListingHandler) DeleteListing(c *gin.Context) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
 	id := c.Param("id")
+	actor := actorFromClaims(claims.(*auth.Claims))
 
-	if err := s.listingUseCase.DeleteListing(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := s.listingUseCase.DeleteListing(actor, id); err != nil {
+		writeListingError(c, err)
 		return
 	}
 
@@ -101,7 +288,12 @@ func (s *ListingHandler) BookmarkListing(c *gin.Context) {
 	listingID := c.Param("listing_id")
 
 	if err := s.listingUseCase.BookmarkListing(userID, listingID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		switch err {
+		case domain.ErrListingNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
@@ -126,6 +318,40 @@ func (s *ListingHandler) UnbookmarkListing(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Listing unbookmarked successfully"})
 }
 
+func (s *ListingHandler) UpdateBookmarkSettings(c *gin.Context) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	userID := claims.(*auth.Claims).UserID
+	listingID := c.Param("listing_id")
+
+	var request domain.BookmarkSettingsRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	if errors := pkg.ValidateStruct(request); len(errors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errors})
+		return
+	}
+
+	if err := s.listingUseCase.SetBookmarkNotify(userID, listingID, *request.Notify); err != nil {
+		switch err {
+		case domain.ErrBookmarkNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"notify": *request.Notify})
+}
+
 func (s *ListingHandler) GetBookmarkedListings(c *gin.Context) {
 	claims, exists := c.Get("claims")
 	if !exists {
@@ -135,11 +361,106 @@ func (s *ListingHandler) GetBookmarkedListings(c *gin.Context) {
 
 	userID := claims.(*auth.Claims).UserID
 
-	listings, err := s.listingUseCase.GetBookmarkedListings(userID)
+	var query domain.CursorQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters"})
+		return
+	}
+
+	if errors := pkg.ValidateStruct(query); len(errors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errors})
+		return
+	}
+
+	listings, err := s.listingUseCase.GetBookmarkedListings(userID, &query)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		switch err {
+		case domain.ErrInvalidCursor:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
 	c.JSON(http.StatusOK, listings)
 }
+
+// viewerID returns the logged-in user on routes behind
+// OptionalJWTAuthMiddleware, or "" for anonymous requests.
+func viewerID(c *gin.Context) string {
+	claims, exists := c.Get("claims")
+	if !exists {
+		return ""
+	}
+	return claims.(*auth.Claims).UserID
+}
+
+// viewerActor returns nil for anonymous requests.
+func viewerActor(c *gin.Context) *domain.Actor {
+	claims, exists := c.Get("claims")
+	if !exists {
+		return nil
+	}
+	return actorFromClaims(claims.(*auth.Claims))
+}
+
+func actorFromClaims(claims *auth.Claims) *domain.Actor {
+	return &domain.Actor{UserID: claims.UserID, IsAdmin: claims.Role == domain.RoleAdmin}
+}
+
+// writeListingError maps errors from listing mutations to HTTP statuses.
+func writeListingError(c *gin.Context, err error) {
+	switch err {
+	case domain.ErrListingNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case domain.ErrForbidden:
+		c.JSON(http.StatusForbidden, gin.H{"error": "You do not own this listing"})
+	case domain.ErrVersionConflict, domain.ErrInvalidTransition:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case domain.ErrNothingToUpdate:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func listingETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+var (
+	errIfMatchRequired = errors.New("If-Match with the listing's ETag is required")
+	errInvalidIfMatch  = errors.New("invalid If-Match header")
+)
+
+// parseIfMatch returns the listing version the client expects. Edits are
+// always conditional, so a missing header or "*" is rejected.
+// requireIfMatch returns the version from the If-Match header. It writes the
+// error response and reports false when the header is missing or malformed.
+func requireIfMatch(c *gin.Context) (int, bool) {
+	version, err := parseIfMatch(c.GetHeader("If-Match"))
+	switch err {
+	case nil:
+		return version, true
+	case errIfMatchRequired:
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+	return 0, false
+}
+
+func parseIfMatch(header string) (int, error) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return 0, errIfMatchRequired
+	}
+
+	header = strings.TrimPrefix(header, "W/")
+	version, err := strconv.Atoi(strings.Trim(header, `"`))
+	if err != nil {
+		return 0, errInvalidIfMatch
+	}
+	return version, nil
+}
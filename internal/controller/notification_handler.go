@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"message-server/internal/controller/auth"
+	"message-server/internal/domain"
+	"message-server/internal/usecases"
+	"message-server/pkg"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationHandler struct {
+	notificationUseCase *usecases.NotificationUseCase
+}
+
+func NewNotificationHandler(notificationUseCase *usecases.NotificationUseCase) *NotificationHandler {
+	return &NotificationHandler{notificationUseCase: notificationUseCase}
+}
+
+func (s *NotificationHandler) GetNotifications(c *gin.Context) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var query domain.CursorQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters"})
+		return
+	}
+
+	if errors := pkg.ValidateStruct(query); len(errors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errors})
+		return
+	}
+
+	notifications, err := s.notificationUseCase.GetNotifications(claims.(*auth.Claims).UserID, &query)
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidCursor:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, notifications)
+}
+
+func (s *NotificationHandler) MarkNotificationRead(c *gin.Context) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	err := s.notificationUseCase.MarkNotificationRead(claims.(*auth.Claims).UserID, c.Param("id"))
+	if err != nil {
+		switch err {
+		case domain.ErrNotificationNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Notification marked as read"})
+}
+
+func (s *NotificationHandler) MarkAllNotificationsRead(c *gin.Context) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	if err := s.notificationUseCase.MarkAllNotificationsRead(claims.(*auth.Claims).UserID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Notifications marked as read"})
+}
@@ -0,0 +1,137 @@
+package controller
+
+import (
+	"message-server/internal/controller/auth"
+	"message-server/internal/domain"
+	"message-server/internal/usecases"
+	"message-server/pkg"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OfferHandler struct {
+	offerUseCase *usecases.OfferUseCase
+}
+
+func NewOfferHandler(offerUseCase *usecases.OfferUseCase) *OfferHandler {
+	return &OfferHandler{offerUseCase: offerUseCase}
+}
+
+func (s *OfferHandler) SubmitOffer(c *gin.Context) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var request domain.OfferRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	if errors := pkg.ValidateStruct(request); len(errors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errors})
+		return
+	}
+
+	offer, err := s.offerUseCase.SubmitOffer(claims.(*auth.Claims).UserID, c.Param("id"), &request)
+	if err != nil {
+		writeOfferError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, offer)
+}
+
+func (s *OfferHandler) CounterOffer(c *gin.Context) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var request domain.OfferRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	if errors := pkg.ValidateStruct(request); len(errors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errors})
+		return
+	}
+
+	offer, err := s.offerUseCase.CounterOffer(claims.(*auth.Claims).UserID, c.Param("id"), &request)
+	if err != nil {
+		writeOfferError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, offer)
+}
+
+func (s *OfferHandler) GetOffer(c *gin.Context) {
+	s.offerAction(c, s.offerUseCase.GetOffer)
+}
+
+func (s *OfferHandler) AcceptOffer(c *gin.Context) {
+	s.offerAction(c, s.offerUseCase.AcceptOffer)
+}
+
+func (s *OfferHandler) RejectOffer(c *gin.Context) {
+	s.offerAction(c, s.offerUseCase.RejectOffer)
+}
+
+func (s *OfferHandler) WithdrawOffer(c *gin.Context) {
+	s.offerAction(c, s.offerUseCase.WithdrawOffer)
+}
+
+func (s *OfferHandler) offerAction(c *gin.Context, action func(userID, id string) (*domain.Offer, error)) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	offer, err := action(claims.(*auth.Claims).UserID, c.Param("id"))
+	if err != nil {
+		writeOfferError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, offer)
+}
+
+func (s *OfferHandler) GetUserOffers(c *gin.Context) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	offers, err := s.offerUseCase.GetUserOffers(claims.(*auth.Claims).UserID)
+	if err != nil {
+		writeOfferError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, offers)
+}
+
+func writeOfferError(c *gin.Context, err error) {
+	switch err {
+	case domain.ErrListingNotFound, domain.ErrOfferNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case domain.ErrForbidden:
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot change this offer"})
+	case domain.ErrOfferAlreadyPending, domain.ErrOfferClosed, domain.ErrOfferExpired,
+		domain.ErrNotYourTurn, domain.ErrListingNotOfferable:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case domain.ErrCannotOfferOwn:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,185 @@
+package controller
+
+import (
+	"message-server/internal/controller/auth"
+	"message-server/internal/domain"
+	"message-server/internal/usecases"
+	"message-server/pkg"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RentalHandler struct {
+	rentalUseCase *usecases.RentalUseCase
+}
+
+func NewRentalHandler(rentalUseCase *usecases.RentalUseCase) *RentalHandler {
+	return &RentalHandler{rentalUseCase: rentalUseCase}
+}
+
+func (s *RentalHandler) GetCalendar(c *gin.Context) {
+	var query domain.CalendarQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters"})
+		return
+	}
+
+	if errors := pkg.ValidateStruct(query); len(errors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errors})
+		return
+	}
+
+	calendar, err := s.rentalUseCase.GetCalendar(c.Param("id"), viewerActor(c), &query)
+	if err != nil {
+		writeRentalError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, calendar)
+}
+
+func (s *RentalHandler) SetRentalPeriod(c *gin.Context) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var request domain.RentalSettingsRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	if errors := pkg.ValidateStruct(request); len(errors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errors})
+		return
+	}
+
+	actor := actorFromClaims(claims.(*auth.Claims))
+	if err := s.rentalUseCase.SetRentalPeriod(actor, c.Param("id"), &request); err != nil {
+		writeRentalError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Rental settings updated"})
+}
+
+func (s *RentalHandler) SetCalendarDates(c *gin.Context) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var request domain.CalendarDatesRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	if errors := pkg.ValidateStruct(request); len(errors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errors})
+		return
+	}
+
+	actor := actorFromClaims(claims.(*auth.Claims))
+	if err := s.rentalUseCase.SetCalendarDates(actor, c.Param("id"), &request); err != nil {
+		writeRentalError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Calendar updated"})
+}
+
+func (s *RentalHandler) RequestReservation(c *gin.Context) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var request domain.ReservationRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	if errors := pkg.ValidateStruct(request); len(errors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errors})
+		return
+	}
+
+	reservation, err := s.rentalUseCase.RequestReservation(claims.(*auth.Claims).UserID, c.Param("id"), &request)
+	if err != nil {
+		writeRentalError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, reservation)
+}
+
+func (s *RentalHandler) GetReservation(c *gin.Context) {
+	s.reservationAction(c, s.rentalUseCase.GetReservation)
+}
+
+func (s *RentalHandler) AcceptReservation(c *gin.Context) {
+	s.reservationAction(c, s.rentalUseCase.AcceptReservation)
+}
+
+func (s *RentalHandler) DeclineReservation(c *gin.Context) {
+	s.reservationAction(c, s.rentalUseCase.DeclineReservation)
+}
+
+func (s *RentalHandler) CancelReservation(c *gin.Context) {
+	s.reservationAction(c, s.rentalUseCase.CancelReservation)
+}
+
+func (s *RentalHandler) reservationAction(c *gin.Context, action func(userID, id string) (*domain.Reservation, error)) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	reservation, err := action(claims.(*auth.Claims).UserID, c.Param("id"))
+	if err != nil {
+		writeRentalError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reservation)
+}
+
+func (s *RentalHandler) GetUserReservations(c *gin.Context) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	reservations, err := s.rentalUseCase.GetUserReservations(claims.(*auth.Claims).UserID)
+	if err != nil {
+		writeRentalError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reservations)
+}
+
+func writeRentalError(c *gin.Context, err error) {
+	switch err {
+	case domain.ErrListingNotFound, domain.ErrReservationNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case domain.ErrForbidden:
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot change this reservation"})
+	case domain.ErrDatesUnavailable, domain.ErrReservationClosed, domain.ErrListingNotReservable:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case domain.ErrNotRentalListing, domain.ErrInvalidDateRange, domain.ErrDatesInPast,
+		domain.ErrStayTooShort, domain.ErrCannotReserveOwn:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,108 @@
+package controller
+
+import (
+	"message-server/internal/controller/auth"
+	"message-server/internal/domain"
+	"message-server/internal/usecases"
+	"message-server/pkg"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReviewHandler struct {
+	reviewUseCase *usecases.ReviewUseCase
+}
+
+func NewReviewHandler(reviewUseCase *usecases.ReviewUseCase) *ReviewHandler {
+	return &ReviewHandler{reviewUseCase: reviewUseCase}
+}
+
+func (s *ReviewHandler) CreateReview(c *gin.Context) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var request domain.ReviewRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	if errors := pkg.ValidateStruct(request); len(errors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errors})
+		return
+	}
+
+	review, err := s.reviewUseCase.CreateReview(claims.(*auth.Claims).UserID, c.Param("id"), &request)
+	if err != nil {
+		writeReviewError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, review)
+}
+
+func (s *ReviewHandler) ReplyToReview(c *gin.Context) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var request domain.ReviewReplyRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	if errors := pkg.ValidateStruct(request); len(errors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errors})
+		return
+	}
+
+	review, err := s.reviewUseCase.ReplyToReview(claims.(*auth.Claims).UserID, c.Param("id"), &request)
+	if err != nil {
+		writeReviewError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, review)
+}
+
+func (s *ReviewHandler) GetListingReviews(c *gin.Context) {
+	reviews, err := s.reviewUseCase.GetListingReviews(c.Param("id"))
+	if err != nil {
+		writeReviewError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reviews)
+}
+
+func (s *ReviewHandler) GetUserReviews(c *gin.Context) {
+	reviews, err := s.reviewUseCase.GetUserReviews(c.Param("username"))
+	if err != nil {
+		writeReviewError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reviews)
+}
+
+func writeReviewError(c *gin.Context, err error) {
+	switch err {
+	case domain.ErrReviewNotFound, domain.ErrUserNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case domain.ErrForbidden:
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only reply to reviews about you"})
+	case domain.ErrReviewNotAllowed:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case domain.ErrAlreadyReviewed, domain.ErrAlreadyReplied:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
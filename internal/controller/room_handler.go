@@ -11,12 +11,12 @@ import (
 )
 
 type ChatHandler struct {
-	roomUseCase usecases.RoomUseCase
+	roomUseCase *usecases.RoomUseCase
 }
 
 func InitRoomHandler(svc *usecases.RoomUseCase) *ChatHandler {
 	return &ChatHandler{
-		roomUseCase: *svc,
+		roomUseCase: svc,
 	}
 }
 
@@ -77,11 +77,67 @@ func (s *ChatHandler) GetRoomMessages(c *gin.Context) {
 		return
 	}
 
-	messages, err := s.roomUseCase.GetMessagesForRoom(roomID)
+	var query domain.CursorQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters"})
+		return
+	}
+
+	errors := pkg.ValidateStruct(query)
+	if len(errors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errors})
+		return
+	}
+
+	messages, err := s.roomUseCase.GetMessagesForRoom(roomID, &query)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get chat rooms"})
+		switch err {
+		case domain.ErrInvalidCursor:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get chat rooms"})
+		}
 		return
 	}
 
 	c.JSON(http.StatusOK, messages)
 }
+
+func (s *ChatHandler) MarkRead(c *gin.Context) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Claims not found"})
+		return
+	}
+
+	user := claims.(*auth.Claims)
+
+	var request domain.MarkReadRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	request.RoomID = c.Param("room_id")
+
+	errors := pkg.ValidateStruct(request)
+	if len(errors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errors})
+		return
+	}
+
+	receipt, err := s.roomUseCase.MarkRead(user.UserID, request.RoomID, request.MessageID)
+	if err != nil {
+		switch err {
+		case domain.ErrRoomNotFound, domain.ErrMessageNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case domain.ErrForbidden:
+			c.JSON(http.StatusForbidden, gin.H{"error": "You are not a member of this room"})
+		default:
+			pkg.Logger.Printf("Failed to mark messages read: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark messages read"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, receipt)
+}
@@ -1,8 +1,10 @@
 package router
 
 import (
+	"expvar"
 	"message-server/internal/controller"
 	"message-server/internal/controller/auth"
+	"message-server/internal/domain"
 	"message-server/internal/usecases"
 
 	"github.com/gin-contrib/cors"
@@ -15,24 +17,48 @@ func NewRouter(
 	listingUseCase *usecases.ListingUseCase,
 	fileUseCase *usecases.FileUseCase,
 	userUseCase *usecases.UserUseCase,
+	amenityUseCase *usecases.AmenityUseCase,
+	notificationUseCase *usecases.NotificationUseCase,
+	savedSearchUseCase *usecases.SavedSearchUseCase,
+	collectionUseCase *usecases.CollectionUseCase,
+	viewingUseCase *usecases.ViewingUseCase,
+	calendarUseCase *usecases.CalendarUseCase,
+	rentalUseCase *usecases.RentalUseCase,
+	offerUseCase *usecases.OfferUseCase,
+	reviewUseCase *usecases.ReviewUseCase,
+	broker domain.MessageBroker,
 ) *gin.Engine {
 	router := gin.Default()
 
 	config := cors.Config{
 		AllowOrigins:     []string{"http://localhost:5173", "https://house-marketplace-581ed5aac951.herokuapp.com"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "If-Match"},
+		ExposeHeaders:    []string{"ETag"},
 		AllowCredentials: true,
 	}
 
 	router.Use(cors.New(config))
 
-	wsHandler := controller.InitMessageHandler(roomUseCase, authUseCase)
+	wsHandler := controller.InitMessageHandler(roomUseCase, authUseCase, broker)
+	notificationUseCase.SetPusher(wsHandler)
+	roomUseCase.SetPusher(wsHandler)
 	roomHandler := controller.InitRoomHandler(roomUseCase)
 	authHandler := controller.NewAuthHandler(authUseCase)
 	listingHandler := controller.NewListingHandler(listingUseCase)
 	fileHandler := controller.NewFileHandler(fileUseCase)
 	userHandler := controller.NewUserHandler(userUseCase)
+	amenityHandler := controller.NewAmenityHandler(amenityUseCase)
+	notificationHandler := controller.NewNotificationHandler(notificationUseCase)
+	savedSearchHandler := controller.NewSavedSearchHandler(savedSearchUseCase)
+	collectionHandler := controller.NewCollectionHandler(collectionUseCase)
+	viewingHandler := controller.NewViewingHandler(viewingUseCase)
+	calendarHandler := controller.NewCalendarHandler(calendarUseCase)
+	rentalHandler := controller.NewRentalHandler(rentalUseCase)
+	offerHandler := controller.NewOfferHandler(offerUseCase)
+	reviewHandler := controller.NewReviewHandler(reviewUseCase)
+
+	optionalAuth := auth.OptionalJWTAuthMiddleware()
 
 	public := router.Group("")
 	{
@@ -40,8 +66,20 @@ func NewRouter(
 		public.POST("/login", authHandler.Login)
 		public.GET("/ws", wsHandler.StartWebSocketServer)
 
-		public.GET("/listing", listingHandler.GetListings)
-		public.GET("/listing/:id", listingHandler.GetListingByID)
+		public.GET("/listing", optionalAuth, listingHandler.GetListings)
+		public.GET("/listing/nearby", optionalAuth, listingHandler.GetNearbyListings)
+		public.GET("/listing/clusters", listingHandler.GetListingClusters)
+		public.GET("/listing/:id", optionalAuth, listingHandler.GetListingByID)
+		public.GET("/listing/:id/price-history", optionalAuth, listingHandler.GetPriceHistory)
+		public.GET("/listing/:id/viewing-slots", optionalAuth, viewingHandler.GetSlots)
+		public.GET("/listing/:id/calendar", optionalAuth, rentalHandler.GetCalendar)
+		public.GET("/listing/:id/reviews", reviewHandler.GetListingReviews)
+		public.GET("/users/:username", userHandler.GetPublicProfile)
+		public.GET("/users/:username/reviews", reviewHandler.GetUserReviews)
+
+		public.GET("/amenities", amenityHandler.GetAmenities)
+		public.GET("/shared/collections/:token", optionalAuth, collectionHandler.GetSharedCollection)
+		public.GET("/calendar/:token", calendarHandler.GetFeed)
 	}
 
 	protected := router.Group("")
@@ -51,23 +89,96 @@ func NewRouter(
 		protected.PUT("/user/info", userHandler.UpdateUserInfo)
 
 		protected.POST("/logout", authHandler.Logout)
+		protected.POST("/ws/ticket", wsHandler.CreateTicket)
+		protected.GET("/me/sessions", wsHandler.GetSessions)
 		protected.POST("/room", roomHandler.CreateRoom)
 		protected.GET("/room", roomHandler.GetRooms)
 		protected.GET("/room/messages/:room_id", roomHandler.GetRoomMessages)
+		protected.POST("/room/:room_id/read", roomHandler.MarkRead)
 
 		protected.POST("/listing", listingHandler.CreateListing)
 		protected.PUT("/listing/:id", listingHandler.UpdateListing)
+		protected.PATCH("/listing/:id", listingHandler.PatchListing)
 		protected.DELETE("/listing/:id", listingHandler.DeleteListing)
+		protected.POST("/listing/:id/publish", listingHandler.PublishListing)
+		protected.POST("/listing/:id/archive", listingHandler.ArchiveListing)
+		protected.POST("/listing/:id/mark-sold", listingHandler.MarkListingSold)
+		protected.GET("/me/listings", listingHandler.GetMyListings)
+
+		protected.POST("/listing/:id/viewing-slots", viewingHandler.CreateSlot)
+		protected.DELETE("/listing/:id/viewing-slots/:slot_id", viewingHandler.DeleteSlot)
+		protected.POST("/viewing-slots/:slot_id/book", viewingHandler.BookViewing)
+		protected.GET("/viewings/:id", viewingHandler.GetViewing)
+		protected.GET("/viewings/:id/ics", calendarHandler.GetViewingCalendar)
+		protected.POST("/viewings/:id/reschedule", viewingHandler.RescheduleViewing)
+		protected.POST("/viewings/:id/cancel", viewingHandler.CancelViewing)
+		protected.GET("/me/viewings", viewingHandler.GetUpcomingViewings)
+
+		protected.PUT("/listing/:id/calendar", rentalHandler.SetRentalPeriod)
+		protected.PUT("/listing/:id/calendar/dates", rentalHandler.SetCalendarDates)
+		protected.POST("/listing/:id/reservations", rentalHandler.RequestReservation)
+		protected.GET("/reservations/:id", rentalHandler.GetReservation)
+		protected.POST("/reservations/:id/accept", rentalHandler.AcceptReservation)
+		protected.POST("/reservations/:id/decline", rentalHandler.DeclineReservation)
+		protected.POST("/reservations/:id/cancel", rentalHandler.CancelReservation)
+		protected.GET("/me/reservations", rentalHandler.GetUserReservations)
+		protected.POST("/me/calendar-feed", calendarHandler.CreateFeedToken)
+		protected.DELETE("/me/calendar-feed", calendarHandler.RevokeFeedToken)
+
+		protected.POST("/listing/:id/offers", offerHandler.SubmitOffer)
+		protected.GET("/offers/:id", offerHandler.GetOffer)
+		protected.POST("/offers/:id/counter", offerHandler.CounterOffer)
+		protected.POST("/offers/:id/accept", offerHandler.AcceptOffer)
+		protected.POST("/offers/:id/reject", offerHandler.RejectOffer)
+		protected.POST("/offers/:id/withdraw", offerHandler.WithdrawOffer)
+		protected.GET("/me/offers", offerHandler.GetUserOffers)
+
+		protected.POST("/listing/:id/reviews", reviewHandler.CreateReview)
+		protected.POST("/reviews/:id/reply", reviewHandler.ReplyToReview)
 
+		protected.GET("/me/notifications", notificationHandler.GetNotifications)
+		protected.POST("/me/notifications/read-all", notificationHandler.MarkAllNotificationsRead)
+		protected.POST("/me/notifications/:id/read", notificationHandler.MarkNotificationRead)
+
+		protected.GET("/me/searches", savedSearchHandler.GetSavedSearches)
+		protected.POST("/me/searches", savedSearchHandler.CreateSavedSearch)
+		protected.GET("/me/searches/:id", savedSearchHandler.GetSavedSearch)
+		protected.PUT("/me/searches/:id", savedSearchHandler.UpdateSavedSearch)
+		protected.DELETE("/me/searches/:id", savedSearchHandler.DeleteSavedSearch)
+
+		protected.PUT("/bookmark/:listing_id", listingHandler.BookmarkListing)
+		// Kept for older clients; behaves like PUT.
 		protected.POST("/bookmark/:listing_id", listingHandler.BookmarkListing)
 		protected.DELETE("/bookmark/:listing_id", listingHandler.UnbookmarkListing)
+		protected.PATCH("/bookmark/:listing_id", listingHandler.UpdateBookmarkSettings)
 		protected.GET("/bookmark", listingHandler.GetBookmarkedListings)
 
+		protected.GET("/bookmark/collections", collectionHandler.GetCollections)
+		protected.POST("/bookmark/collections", collectionHandler.CreateCollection)
+		protected.GET("/bookmark/collections/:id", collectionHandler.GetCollection)
+		protected.PUT("/bookmark/collections/:id", collectionHandler.RenameCollection)
+		protected.DELETE("/bookmark/collections/:id", collectionHandler.DeleteCollection)
+		protected.PUT("/bookmark/collections/:id/items/:listing_id", collectionHandler.SaveCollectionItem)
+		protected.DELETE("/bookmark/collections/:id/items/:listing_id", collectionHandler.RemoveCollectionItem)
+		protected.POST("/bookmark/collections/:id/collaborators", collectionHandler.AddCollaborator)
+		protected.DELETE("/bookmark/collections/:id/collaborators/:user_id", collectionHandler.RemoveCollaborator)
+		protected.POST("/bookmark/collections/:id/share", collectionHandler.ShareCollection)
+		protected.DELETE("/bookmark/collections/:id/share", collectionHandler.UnshareCollection)
+
 		protected.POST("/upload/listing", fileHandler.GenerateListingUploadURL)
 		protected.POST("/upload/avatar", fileHandler.GenerateAvatarUploadURL)
 		protected.GET("/download", fileHandler.GenerateDownloadURL)
 		protected.DELETE("/file", fileHandler.DeleteFile)
 	}
 
+	admin := router.Group("/admin")
+	admin.Use(auth.JWTAuthMiddleware(), auth.RequireRole(domain.RoleAdmin))
+	{
+		admin.POST("/amenities", amenityHandler.CreateAmenity)
+		admin.PUT("/amenities/:id", amenityHandler.UpdateAmenity)
+		admin.DELETE("/amenities/:id", amenityHandler.DeleteAmenity)
+		admin.GET("/debug/vars", gin.WrapH(expvar.Handler()))
+	}
+
 	return router
 }
@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"message-server/internal/controller/auth"
+	"message-server/internal/domain"
+	"message-server/internal/usecases"
+	"message-server/pkg"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SavedSearchHandler struct {
+	savedSearchUseCase *usecases.SavedSearchUseCase
+}
+
+func NewSavedSearchHandler(savedSearchUseCase *usecases.SavedSearchUseCase) *SavedSearchHandler {
+	return &SavedSearchHandler{savedSearchUseCase: savedSearchUseCase}
+}
+
+func (s *SavedSearchHandler) CreateSavedSearch(c *gin.Context) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var request domain.SavedSearchRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	if errors := pkg.ValidateStruct(request); len(errors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errors})
+		return
+	}
+
+	search, err := s.savedSearchUseCase.CreateSavedSearch(claims.(*auth.Claims).UserID, &request)
+	if err != nil {
+		writeSavedSearchError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, search)
+}
+
+func (s *SavedSearchHandler) GetSavedSearches(c *gin.Context) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	searches, err := s.savedSearchUseCase.GetSavedSearches(claims.(*auth.Claims).UserID)
+	if err != nil {
+		writeSavedSearchError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, searches)
+}
+
+func (s *SavedSearchHandler) GetSavedSearch(c *gin.Context) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	search, err := s.savedSearchUseCase.GetSavedSearch(claims.(*auth.Claims).UserID, c.Param("id"))
+	if err != nil {
+		writeSavedSearchError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, search)
+}
+
+func (s *SavedSearchHandler) UpdateSavedSearch(c *gin.Context) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var request domain.SavedSearchRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	if errors := pkg.ValidateStruct(request); len(errors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errors})
+		return
+	}
+
+	search, err := s.savedSearchUseCase.UpdateSavedSearch(claims.(*auth.Claims).UserID, c.Param("id"), &request)
+	if err != nil {
+		writeSavedSearchError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, search)
+}
+
+func (s *SavedSearchHandler) DeleteSavedSearch(c *gin.Context) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	if err := s.savedSearchUseCase.DeleteSavedSearch(claims.(*auth.Claims).UserID, c.Param("id")); err != nil {
+		writeSavedSearchError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Saved search deleted successfully"})
+}
+
+func writeSavedSearchError(c *gin.Context, err error) {
+	switch err {
+	case domain.ErrSavedSearchNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case domain.ErrInvalidBoundingBox, domain.ErrInvalidDateRange, domain.ErrIncompleteDates:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
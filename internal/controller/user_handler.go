@@ -56,3 +56,18 @@ func (s *UserHandler) UpdateUserInfo(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "User updated successfully"})
 }
+
+func (s *UserHandler) GetPublicProfile(c *gin.Context) {
+	profile, err := s.userUseCase.GetPublicProfile(c.Param("username"))
+	if err != nil {
+		switch err {
+		case domain.ErrUserNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
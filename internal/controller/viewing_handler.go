@@ -0,0 +1,179 @@
+package controller
+
+import (
+	"message-server/internal/controller/auth"
+	"message-server/internal/domain"
+	"message-server/internal/usecases"
+	"message-server/pkg"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ViewingHandler struct {
+	viewingUseCase *usecases.ViewingUseCase
+}
+
+func NewViewingHandler(viewingUseCase *usecases.ViewingUseCase) *ViewingHandler {
+	return &ViewingHandler{viewingUseCase: viewingUseCase}
+}
+
+func (s *ViewingHandler) CreateSlot(c *gin.Context) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var request domain.ViewingSlotRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	if errors := pkg.ValidateStruct(request); len(errors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errors})
+		return
+	}
+
+	actor := actorFromClaims(claims.(*auth.Claims))
+	slot, err := s.viewingUseCase.CreateSlot(actor, c.Param("id"), &request)
+	if err != nil {
+		writeViewingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, slot)
+}
+
+func (s *ViewingHandler) GetSlots(c *gin.Context) {
+	slots, err := s.viewingUseCase.GetSlots(c.Param("id"), viewerActor(c))
+	if err != nil {
+		writeViewingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, slots)
+}
+
+func (s *ViewingHandler) DeleteSlot(c *gin.Context) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	actor := actorFromClaims(claims.(*auth.Claims))
+	if err := s.viewingUseCase.DeleteSlot(actor, c.Param("id"), c.Param("slot_id")); err != nil {
+		writeViewingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Viewing slot deleted"})
+}
+
+func (s *ViewingHandler) BookViewing(c *gin.Context) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	viewing, err := s.viewingUseCase.BookViewing(claims.(*auth.Claims).UserID, c.Param("slot_id"))
+	if err != nil {
+		writeViewingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, viewing)
+}
+
+func (s *ViewingHandler) RescheduleViewing(c *gin.Context) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var request domain.RescheduleViewingRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	if errors := pkg.ValidateStruct(request); len(errors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errors})
+		return
+	}
+
+	viewing, err := s.viewingUseCase.RescheduleViewing(claims.(*auth.Claims).UserID, c.Param("id"), &request)
+	if err != nil {
+		writeViewingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, viewing)
+}
+
+func (s *ViewingHandler) CancelViewing(c *gin.Context) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	viewing, err := s.viewingUseCase.CancelViewing(claims.(*auth.Claims).UserID, c.Param("id"))
+	if err != nil {
+		writeViewingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, viewing)
+}
+
+func (s *ViewingHandler) GetViewing(c *gin.Context) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	viewing, err := s.viewingUseCase.GetViewing(claims.(*auth.Claims).UserID, c.Param("id"))
+	if err != nil {
+		writeViewingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, viewing)
+}
+
+func (s *ViewingHandler) GetUpcomingViewings(c *gin.Context) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	viewings, err := s.viewingUseCase.GetUpcomingViewings(claims.(*auth.Claims).UserID)
+	if err != nil {
+		writeViewingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, viewings)
+}
+
+func writeViewingError(c *gin.Context, err error) {
+	switch err {
+	case domain.ErrListingNotFound, domain.ErrSlotNotFound, domain.ErrViewingNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case domain.ErrForbidden:
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot change this viewing"})
+	case domain.ErrDuplicateSlot, domain.ErrSlotAlreadyBooked, domain.ErrViewingNotActive, domain.ErrViewingUnavailable:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case domain.ErrSlotInPast, domain.ErrCannotBookOwnListing:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
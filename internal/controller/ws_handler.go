@@ -1,19 +1,24 @@
 package controller
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
 	"fmt"
 	"io"
 
+	"message-server/internal/controller/auth"
 	"message-server/internal/domain"
 	"message-server/internal/usecases"
 	"message-server/pkg"
 	"net/http"
 	"os"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 	"github.com/joho/godotenv"
 )
@@ -26,14 +31,19 @@ const (
 )
 
 type MessageServer struct {
-	roomUseCase usecases.RoomUseCase
+	roomUseCase *usecases.RoomUseCase
 	authUseCase usecases.AuthUseCase
 	upgrader    websocket.Upgrader
-	clients     map[string]*websocket.Conn
-	mutex       sync.RWMutex
+	hub         *Hub
+	// legacyAuth accepts connections that identify themselves with an auth
+	// frame carrying a bare user ID. Deprecated: it lets anyone who knows a
+	// user's ID connect as them. Enabled with WS_LEGACY_AUTH=true.
+	legacyAuth bool
 }
 
-func InitMessageHandler(svc *usecases.RoomUseCase, authSvc *usecases.AuthUseCase) *MessageServer {
+var errNoWSCredentials = errors.New("no credentials")
+
+func InitMessageHandler(svc *usecases.RoomUseCase, authSvc *usecases.AuthUseCase, broker domain.MessageBroker) *MessageServer {
 	godotenv.Load()
 	frontURL := os.Getenv("FRONTEND_URL")
 
@@ -53,15 +63,52 @@ func InitMessageHandler(svc *usecases.RoomUseCase, authSvc *usecases.AuthUseCase
 		},
 	}
 
+	legacyAuth := os.Getenv("WS_LEGACY_AUTH") == "true"
+	if legacyAuth {
+		pkg.Logger.Println("Warning: WS_LEGACY_AUTH is deprecated and lets clients connect as any user")
+	}
+
+	hub := newHub(broker)
+	if err := hub.start(context.Background()); err != nil {
+		panic(err)
+	}
+	wsMetrics.Set("connections", expvar.Func(hub.connectionCount))
+	wsMetrics.Set("queue_depth", expvar.Func(hub.queueDepth))
+
 	return &MessageServer{
-		roomUseCase: *svc,
+		roomUseCase: svc,
 		authUseCase: *authSvc,
 		upgrader:    upgrader,
-		clients:     make(map[string]*websocket.Conn),
+		hub:         hub,
+		legacyAuth:  legacyAuth,
 	}
 }
 
+// CreateTicket issues a WebSocket ticket for clients that can't send the
+// auth cookie with the upgrade request. It is passed as /ws?ticket=...
+func (s *MessageServer) CreateTicket(c *gin.Context) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	ticket, err := auth.GenerateWSTicket(claims.(*auth.Claims).UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create ticket"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"ticket": ticket, "expires_in": int(auth.WSTicketTTL.Seconds())})
+}
+
 func (s *MessageServer) StartWebSocketServer(c *gin.Context) {
+	userID, err := s.authenticateUpgrade(c)
+	if err != nil && !(err == errNoWSCredentials && s.legacyAuth) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication cookie or ticket is required"})
+		return
+	}
+
 	conn, err := s.upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		pkg.Logger.Printf("WebSocket upgrade failed: %v", err)
@@ -75,6 +122,42 @@ func (s *MessageServer) StartWebSocketServer(c *gin.Context) {
 		return nil
 	})
 
+	if userID == "" {
+		var ok bool
+		if userID, ok = s.readLegacyAuth(conn); !ok {
+			return
+		}
+	}
+
+	client := newWSClient(uuid.NewString(), userID, c.Request.UserAgent(), conn)
+	s.hub.register(client)
+	go client.writePump()
+
+	client.enqueue(domain.MessageResponse{
+		Type:         "auth_success",
+		Status:       "connected",
+		ConnectionID: client.id,
+		Timestamp:    time.Now().Unix(),
+	})
+	pkg.Logger.Printf("User %s connected as %s", userID, client.id)
+
+	s.handleMessages(client)
+}
+
+// GetSessions lists the devices the user is connected from.
+func (s *MessageServer) GetSessions(c *gin.Context) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	c.JSON(http.StatusOK, s.hub.sessions(claims.(*auth.Claims).UserID))
+}
+
+// readLegacyAuth reads the deprecated auth frame and returns the user ID it
+// claims. It closes the connection when the frame is invalid.
+func (s *MessageServer) readLegacyAuth(conn *websocket.Conn) (string, bool) {
 	var authMessage domain.AuthMessage
 	if err := conn.ReadJSON(&authMessage); err != nil {
 		pkg.Logger.Println("Failed to read auth message:", err)
@@ -83,7 +166,7 @@ func (s *MessageServer) StartWebSocketServer(c *gin.Context) {
 			Error: "Authentication failed: " + err.Error(),
 		})
 		conn.Close()
-		return
+		return "", false
 	}
 
 	if !validateAuthMessage(&authMessage, &s.authUseCase) {
@@ -93,72 +176,19 @@ func (s *MessageServer) StartWebSocketServer(c *gin.Context) {
 			Error: "Invalid authentication message",
 		})
 		conn.Close()
-		return
+		return "", false
 	}
 
-	userID := authMessage.UserID
-
-	var oldConn *websocket.Conn
-	var exists bool
-
-	s.mutex.Lock()
-	oldConn, exists = s.clients[userID]
-	s.clients[userID] = conn
-	s.mutex.Unlock()
-
-	if exists {
-		pkg.Logger.Printf("User %s already has an active connection, closing old one", userID)
-		oldConn.WriteJSON(domain.MessageResponse{
-			Type:   "disconnect",
-			Status: "replaced",
-			Error:  "New connection established from another device",
-		})
-		oldConn.Close()
-	}
-
-	conn.WriteJSON(domain.MessageResponse{
-		Type:      "auth_success",
-		Status:    "connected",
-		Timestamp: time.Now().Unix(),
-	})
-	pkg.Logger.Printf("User %s connected", userID)
-
-	go s.ping(conn, userID)
-
-	s.handleMessages(conn, userID)
-}
-
-func (s *MessageServer) ping(conn *websocket.Conn, userID string) {
-	ticker := time.NewTicker(pingPeriod)
-	defer func() {
-		ticker.Stop()
-		s.mutex.Lock()
-		if s.clients[userID] == conn {
-			delete(s.clients, userID)
-			conn.Close()
-		}
-		s.mutex.Unlock()
-	}()
-
-	for range ticker.C {
-		conn.SetWriteDeadline(time.Now().Add(writeWait))
-		if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
-			pkg.Logger.Printf("Ping failed, terminating connection: %v", err)
-			return
-		}
-	}
+	pkg.Logger.Printf("User %s connected with the deprecated auth frame", authMessage.UserID)
+	return authMessage.UserID, true
 }
 
-func (s *MessageServer) handleMessages(conn *websocket.Conn, senderID string) {
+func (s *MessageServer) handleMessages(client *wsClient) {
+	conn, senderID := client.conn, client.userID
 	defer func() {
-		s.mutex.Lock()
-		if s.clients[senderID] == conn {
-			delete(s.clients, senderID)
-		}
-		s.mutex.Unlock()
-
-		conn.Close()
-		pkg.Logger.Printf("User %s disconnected", senderID)
+		s.hub.unregister(client)
+		client.close()
+		pkg.Logger.Printf("User %s disconnected from %s", senderID, client.id)
 	}()
 
 	for {
@@ -186,9 +216,14 @@ func (s *MessageServer) handleMessages(conn *websocket.Conn, senderID string) {
 			continue
 		}
 
+		if message.Type == domain.ChatFrameRead {
+			s.markRead(client, &message)
+			continue
+		}
+
 		if err := validateChatMessage(&message); err != nil {
 			pkg.Logger.Println("Invalid message format:", message)
-			s.writeJSON(conn, domain.MessageResponse{
+			client.enqueue(domain.MessageResponse{
 				Type:      "error",
 				Error:     err.Error(),
 				Timestamp: time.Now().Unix(),
@@ -198,7 +233,7 @@ func (s *MessageServer) handleMessages(conn *websocket.Conn, senderID string) {
 
 		if message.SenderID != "" && message.SenderID != senderID {
 			pkg.Logger.Printf("Message sender ID mismatch: auth=%s, message=%s", senderID, message.SenderID)
-			s.writeJSON(conn, domain.MessageResponse{
+			client.enqueue(domain.MessageResponse{
 				Type:      "error",
 				Error:     "Sender ID in message doesn't match authenticated user",
 				Timestamp: time.Now().Unix(),
@@ -213,7 +248,7 @@ func (s *MessageServer) handleMessages(conn *websocket.Conn, senderID string) {
 		exists, err := s.roomUseCase.CheckRoomExists(message.RoomID)
 		if err != nil {
 			pkg.Logger.Println("Failed to check room existence:", err)
-			s.writeJSON(conn, domain.MessageResponse{
+			client.enqueue(domain.MessageResponse{
 				Type:      "error",
 				Error:     "Database error when validating room",
 				Timestamp: time.Now().Unix(),
@@ -223,7 +258,7 @@ func (s *MessageServer) handleMessages(conn *websocket.Conn, senderID string) {
 
 		if !exists {
 			pkg.Logger.Println("Room does not exist:", message.RoomID)
-			s.writeJSON(conn, domain.MessageResponse{
+			client.enqueue(domain.MessageResponse{
 				Type:      "error",
 				Error:     "Room does not exist",
 				Timestamp: time.Now().Unix(),
@@ -234,7 +269,7 @@ func (s *MessageServer) handleMessages(conn *websocket.Conn, senderID string) {
 		isMember, err := s.roomUseCase.CheckUserInRoom(senderID, message.RoomID)
 		if err != nil {
 			pkg.Logger.Println("Failed to check room membership:", err)
-			s.writeJSON(conn, domain.MessageResponse{
+			client.enqueue(domain.MessageResponse{
 				Type:      "error",
 				Error:     "Database error when validating room membership",
 				Timestamp: time.Now().Unix(),
@@ -244,7 +279,7 @@ func (s *MessageServer) handleMessages(conn *websocket.Conn, senderID string) {
 
 		if !isMember {
 			pkg.Logger.Printf("User %s is not a member of room %s", senderID, message.RoomID)
-			s.writeJSON(conn, domain.MessageResponse{
+			client.enqueue(domain.MessageResponse{
 				Type:      "error",
 				Error:     "You are not a member of this room",
 				Timestamp: time.Now().Unix(),
@@ -255,7 +290,7 @@ func (s *MessageServer) handleMessages(conn *websocket.Conn, senderID string) {
 		receiverIsMember, err := s.roomUseCase.CheckUserInRoom(message.ReceiverID, message.RoomID)
 		if err != nil {
 			pkg.Logger.Println("Failed to check receiver room membership:", err)
-			s.writeJSON(conn, domain.MessageResponse{
+			client.enqueue(domain.MessageResponse{
 				Type:      "error",
 				Error:     "Database error when validating receiver room membership",
 				Timestamp: time.Now().Unix(),
@@ -265,7 +300,7 @@ func (s *MessageServer) handleMessages(conn *websocket.Conn, senderID string) {
 
 		if !receiverIsMember {
 			pkg.Logger.Printf("Receiver %s is not a member of room %s", message.ReceiverID, message.RoomID)
-			s.writeJSON(conn, domain.MessageResponse{
+			client.enqueue(domain.MessageResponse{
 				Type:      "error",
 				Error:     "Receiver is not a member of this room",
 				Timestamp: time.Now().Unix(),
@@ -274,9 +309,10 @@ func (s *MessageServer) handleMessages(conn *websocket.Conn, senderID string) {
 		}
 
 		timestamp := time.Now().Unix()
-		if err := s.roomUseCase.SaveMessage(message.Text, senderID, message.RoomID); err != nil {
+		messageID, err := s.roomUseCase.SaveMessage(message.Text, senderID, message.RoomID)
+		if err != nil {
 			pkg.Logger.Printf("Error saving message to database: %v", err)
-			s.writeJSON(conn, domain.MessageResponse{
+			client.enqueue(domain.MessageResponse{
 				Type:      "error",
 				Error:     "Failed to save message",
 				Timestamp: timestamp,
@@ -284,55 +320,121 @@ func (s *MessageServer) handleMessages(conn *websocket.Conn, senderID string) {
 			continue
 		}
 
-		delivered := s.sendMessage(senderID, message.ReceiverID, message.Text, message.RoomID)
-
-		status := "sent"
-		if delivered {
-			status = "delivered"
-		}
+		s.sendMessage(client, message.ReceiverID, messageID, message.Text, message.RoomID, timestamp)
+	}
+}
 
-		s.writeJSON(conn, domain.MessageResponse{
-			Type:      "status",
-			Status:    status,
-			Text:      message.Text,
-			Timestamp: timestamp,
+// markRead handles a read frame. The receipt itself is pushed by the use case.
+func (s *MessageServer) markRead(client *wsClient, message *domain.ChatMessage) {
+	request := domain.MarkReadRequest{RoomID: message.RoomID, MessageID: message.MessageID}
+	if len(pkg.ValidateStruct(request)) > 0 {
+		client.enqueue(domain.MessageResponse{
+			Type:      "error",
+			Error:     "A valid room_id and message_id are required",
+			Timestamp: time.Now().Unix(),
 		})
+		return
+	}
+
+	_, err := s.roomUseCase.MarkRead(client.userID, request.RoomID, request.MessageID)
+	if err == nil {
+		return
+	}
+
+	text := "Failed to mark messages read"
+	switch err {
+	case domain.ErrRoomNotFound:
+		text = "Room does not exist"
+	case domain.ErrMessageNotFound:
+		text = "Message does not exist in this room"
+	case domain.ErrForbidden:
+		text = "You are not a member of this room"
+	default:
+		pkg.Logger.Printf("Failed to mark messages read: %v", err)
 	}
+	client.enqueue(domain.MessageResponse{
+		Type:      "error",
+		Error:     text,
+		Timestamp: time.Now().Unix(),
+	})
 }
 
-func (s *MessageServer) writeJSON(conn *websocket.Conn, message any) bool {
-	if conn == nil {
-		return false
+// sendMessage delivers a chat message to every device of the receiver and
+// echoes it to the sender's other devices, then tells the sender whether it
+// was delivered. When no receiver device is connected to this instance, the
+// sender gets "sent" first and "delivered" later if another instance
+// delivers it.
+func (s *MessageServer) sendMessage(sender *wsClient, receiverID, messageID, text, roomID string, timestamp int64) {
+	response := &domain.MessageResponse{
+		ID:        messageID,
+		Type:      "message",
+		Text:      text,
+		SenderID:  sender.userID,
+		RoomID:    roomID,
+		Timestamp: time.Now().Unix(),
+	}
+	status := func(status string) *domain.MessageResponse {
+		return &domain.MessageResponse{ID: messageID, Type: "status", Status: status, Text: text, Timestamp: timestamp}
 	}
 
-	conn.SetWriteDeadline(time.Now().Add(writeWait))
-	if err := conn.WriteJSON(message); err != nil {
-		pkg.Logger.Printf("Error writing to WebSocket: %v", err)
-		return false
+	s.hub.send(sender.userID, response, sender)
+	if s.hub.deliver(receiverID, response, nil) > 0 {
+		s.hub.send(sender.userID, status("delivered"), nil)
+		s.hub.publish(receiverID, response, nil)
+		return
+	}
+
+	s.hub.send(sender.userID, status("sent"), nil)
+
+	var receipt *domain.BrokerMessage
+	if frame, err := json.Marshal(status("delivered")); err == nil {
+		receipt = &domain.BrokerMessage{UserID: sender.userID, Frame: frame}
 	}
-	return true
+	s.hub.publish(receiverID, response, receipt)
 }
 
-func (s *MessageServer) sendMessage(senderID, receiverID, text, roomID string) bool {
-	s.mutex.RLock()
-	receiverConn, receiverConnected := s.clients[receiverID]
-	s.mutex.RUnlock()
-
-	if receiverConnected {
-		response := domain.MessageResponse{
-			Type:      "message",
-			Text:      text,
-			SenderID:  senderID,
-			RoomID:    roomID,
-			Timestamp: time.Now().Unix(),
+// PushMessage implements domain.MessagePusher. It reports whether a device
+// connected to this instance got the message; other instances are reached
+// through the broker regardless.
+func (s *MessageServer) PushMessage(userID string, message *domain.MessageResponse) bool {
+	return s.hub.send(userID, message, nil) > 0
+}
+
+// PushNotification implements domain.NotificationPusher.
+func (s *MessageServer) PushNotification(userID string, notification *domain.Notification) bool {
+	return s.PushMessage(userID, &domain.MessageResponse{
+		Type:         "notification",
+		Notification: notification,
+		Timestamp:    time.Now().Unix(),
+	})
+}
+
+// authenticateUpgrade returns the user ID from the ticket query parameter or,
+// failing that, the auth cookie. A ticket is spent by the first upgrade that
+// presents it. It returns errNoWSCredentials when the request carries
+// neither.
+func (s *MessageServer) authenticateUpgrade(c *gin.Context) (string, error) {
+	if ticket := c.Query("ticket"); ticket != "" {
+		claims, err := auth.ValidateWSTicket(ticket)
+		if err != nil {
+			return "", err
 		}
+		if err := s.authUseCase.UseWSTicket(claims.ID, claims.ExpiresAt.Time); err != nil {
+			return "", err
+		}
+		return claims.UserID, nil
+	}
 
-		s.writeJSON(receiverConn, response)
-		return true
+	token, err := c.Cookie("auth_token")
+	if err != nil {
+		return "", errNoWSCredentials
 	}
 
-	pkg.Logger.Printf("Recipient %s not connected or delivery failed, message saved to DB only", receiverID)
-	return false
+	claims, err := auth.ValidateToken(token)
+	if err != nil {
+		return "", err
+	}
+	return claims.UserID, nil
 }
 
 func validateAuthMessage(authMessage *domain.AuthMessage, authUseCase *usecases.AuthUseCase) bool {
@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"message-server/internal/controller/auth"
+	"message-server/internal/domain"
+	"message-server/internal/repository"
+	"message-server/internal/usecases"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type fakeRoomRepository struct {
+	domain.RoomRepository
+	room   *domain.Room
+	readAt time.Time
+}
+
+func (r *fakeRoomRepository) GetRoom(roomID string) (*domain.Room, error) {
+	if roomID != r.room.RoomID {
+		return nil, domain.ErrRoomNotFound
+	}
+	return r.room, nil
+}
+
+func (r *fakeRoomRepository) MarkMessagesRead(roomID, readerID, messageID string) (*time.Time, error) {
+	return &r.readAt, nil
+}
+
+type fakeTicketRepository struct {
+	domain.AuthRepository
+	used map[string]bool
+}
+
+func (r *fakeTicketRepository) UseWSTicket(id string, expiresAt time.Time) error {
+	if r.used[id] {
+		return domain.ErrTicketUsed
+	}
+	r.used[id] = true
+	return nil
+}
+
+func TestMessageServer_AuthenticateUpgrade_TicketIsSingleUse(t *testing.T) {
+	server := &MessageServer{
+		authUseCase: *usecases.NewAuthUseCase(&fakeTicketRepository{used: map[string]bool{}}),
+	}
+
+	ticket, err := auth.GenerateWSTicket("alice")
+	if err != nil {
+		t.Fatalf("GenerateWSTicket() error = %v", err)
+	}
+
+	upgrade := func() (string, error) {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("GET", "/ws?ticket="+url.QueryEscape(ticket), nil)
+		return server.authenticateUpgrade(c)
+	}
+
+	userID, err := upgrade()
+	if err != nil || userID != "alice" {
+		t.Fatalf("first authenticateUpgrade() = %q, %v, want alice", userID, err)
+	}
+	if _, err := upgrade(); err != domain.ErrTicketUsed {
+		t.Errorf("second authenticateUpgrade() error = %v, want %v", err, domain.ErrTicketUsed)
+	}
+}
+
+func TestMessageServer_MarkRead_PushesReceiptToBothParticipants(t *testing.T) {
+	roomID := "5f0c6e8e-4a4b-4c1e-9a53-2f7c9d0b6a11"
+	messageID := "0d7b9a52-2c8e-4f3b-8f61-3b9e6c1d4e27"
+	rooms := &fakeRoomRepository{room: &domain.Room{RoomID: roomID, OwnerID: "owner", CustomerID: "customer"}}
+	roomUseCase := usecases.NewRoomUseCase(rooms, nil, nil)
+
+	// Wired in the same order as the router: the pusher is set after the
+	// handler is built.
+	server := InitMessageHandler(roomUseCase, usecases.NewAuthUseCase(nil), repository.NewMemoryBroker())
+	roomUseCase.SetPusher(server)
+
+	customer := newTestClient(server.hub, "customer", "customer")
+	owner := newTestClient(server.hub, "owner", "owner")
+
+	server.markRead(customer, &domain.ChatMessage{Type: domain.ChatFrameRead, RoomID: roomID, MessageID: messageID})
+
+	for _, client := range []*wsClient{owner, customer} {
+		if got := receiveFrame(t, client); !strings.Contains(got, `"type":"`+domain.MessageTypeReadReceipt+`"`) {
+			t.Errorf("%s received %s, want a read receipt", client.id, got)
+		}
+	}
+}
+
+func TestMessageServer_MarkRead_RejectsInvalidRoomID(t *testing.T) {
+	rooms := &fakeRoomRepository{room: &domain.Room{RoomID: "room-1", OwnerID: "owner", CustomerID: "customer"}}
+	server := InitMessageHandler(usecases.NewRoomUseCase(rooms, nil, nil), usecases.NewAuthUseCase(nil), repository.NewMemoryBroker())
+	customer := newTestClient(server.hub, "customer", "customer")
+
+	server.markRead(customer, &domain.ChatMessage{Type: domain.ChatFrameRead, RoomID: "room-1", MessageID: "0d7b9a52-2c8e-4f3b-8f61-3b9e6c1d4e27"})
+
+	if got := receiveFrame(t, customer); !strings.Contains(got, "A valid room_id and message_id are required") {
+		t.Errorf("customer received %s, want a validation error", got)
+	}
+}
@@ -0,0 +1,29 @@
+package domain
+
+import "errors"
+
+type Amenity struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Icon     string `json:"icon"`
+	Category string `json:"category"`
+}
+
+type AmenityRequest struct {
+	Name     string `json:"name" validate:"required,max=100"`
+	Icon     string `json:"icon" validate:"max=100"`
+	Category string `json:"category" validate:"required,max=100"`
+}
+
+type AmenityRepository interface {
+	CreateAmenity(req *AmenityRequest) (string, error)
+	GetAmenities() ([]Amenity, error)
+	UpdateAmenity(id string, req *AmenityRequest) error
+	DeleteAmenity(id string) error
+}
+
+var (
+	ErrAmenityNotFound  = errors.New("amenity not found")
+	ErrDuplicateAmenity = errors.New("amenity already exists")
+	ErrLegacyAmenity    = errors.New("amenity backs a legacy listing field and cannot be deleted")
+)
@@ -1,16 +1,25 @@
 package domain
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 type User struct {
 	ID        string `json:"id"`
 	FullName  string `json:"full_name"`
 	Username  string `json:"username"`
 	Email     string `json:"email"`
-	Password  string `json:"password"`
+	Password  string `json:"-"`
 	AvatarKey string `json:"avatar_key"`
+	Role      string `json:"role"`
 }
 
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 type RegisterRequest struct {
 	FullName  string `json:"full_name"`
 	Username  string `json:"username"`
@@ -27,12 +36,17 @@ type LoginRequest struct {
 
 type AuthRepository interface {
 	CreateUser(name, username, email, password string) error
+	// GetUserByUsername returns ErrUserNotFound for an unknown username.
 	GetUserByUsername(username string) (*User, error)
 	GetUserByEmail(email string) (*User, error)
 	GetUserByID(id string) (*User, error)
 	UpdateUser(name, avatarURL string, userID string) error
 	CheckUserExists(userID string) (bool, error)
 	CheckUserCredentialsExist(username, email string) error
+	// UseWSTicket records that the WebSocket ticket with the given ID has
+	// been used. It returns ErrTicketUsed if it already was. The record is
+	// kept until the ticket expires.
+	UseWSTicket(id string, expiresAt time.Time) error
 }
 
 var (
@@ -42,4 +56,17 @@ var (
 	ErrDuplicateUsername  = errors.New("username already exists")
 	ErrDuplicateEmail     = errors.New("email already exists")
 	ErrDatabaseError      = errors.New("database error")
+	ErrForbidden          = errors.New("forbidden")
+	ErrTicketUsed         = errors.New("ticket already used")
 )
+
+// Actor is the authenticated user on whose behalf a use case runs.
+type Actor struct {
+	UserID  string
+	IsAdmin bool
+}
+
+// CanModify reports whether the actor may change a resource owned by ownerID.
+func (a *Actor) CanModify(ownerID string) bool {
+	return a.IsAdmin || a.UserID == ownerID
+}
@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// BrokerMessage is a frame for one user's connections, passed between server
+// instances so it reaches devices connected elsewhere.
+type BrokerMessage struct {
+	// ID is set on receipts. Every instance that delivers Frame sends the
+	// receipt on, so copies with an ID already seen are dropped.
+	ID     string          `json:"id,omitempty"`
+	Origin string          `json:"origin"`
+	UserID string          `json:"user_id"`
+	Frame  json.RawMessage `json:"frame"`
+	// Receipt is sent on by whichever instance delivers Frame to at least one
+	// device, e.g. a "delivered" status back to the sender.
+	Receipt *BrokerMessage `json:"receipt,omitempty"`
+}
+
+// MessageBroker fans chat frames out to every server instance, including the
+// one that published them.
+type MessageBroker interface {
+	Publish(ctx context.Context, message *BrokerMessage) error
+	// Subscribe returns once the subscription is active. The handler is
+	// called for every published message until ctx is cancelled.
+	Subscribe(ctx context.Context, handler func(message *BrokerMessage)) error
+}
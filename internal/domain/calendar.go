@@ -0,0 +1,18 @@
+package domain
+
+import "errors"
+
+type CalendarFeedResponse struct {
+	// Token is only shown once; the server keeps a hash of it.
+	Token string `json:"token"`
+	URL   string `json:"url"`
+}
+
+type CalendarRepository interface {
+	// SetFeedToken replaces the user's feed token, revoking the old one.
+	SetFeedToken(userID, tokenHash string) error
+	DeleteFeedToken(userID string) error
+	GetUserIDByFeedToken(tokenHash string) (string, error)
+}
+
+var ErrCalendarFeedNotFound = errors.New("calendar feed not found")
@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// BookmarkCollection is a named group of bookmarks. The owner can invite
+// collaborators, who may add and remove items, and can publish a signed
+// read-only link.
+type BookmarkCollection struct {
+	ID        string `json:"id"`
+	OwnerID   string `json:"owner_id"`
+	Name      string `json:"name"`
+	ItemCount int    `json:"item_count"`
+	// IsShared reports whether a share link is currently valid.
+	IsShared  bool      `json:"is_shared"`
+	CreatedAt time.Time `json:"created_at"`
+	// ShareNonce is mixed into share tokens; rotating or clearing it revokes
+	// links handed out earlier.
+	ShareNonce *string `json:"-"`
+}
+
+type CollectionItem struct {
+	Listing ListingInfo `json:"listing"`
+	Note    string      `json:"note"`
+	AddedBy string      `json:"added_by"`
+	AddedAt time.Time   `json:"added_at"`
+}
+
+type CollectionCollaborator struct {
+	UserID   string    `json:"user_id"`
+	Username string    `json:"username"`
+	FullName string    `json:"full_name"`
+	AddedAt  time.Time `json:"added_at"`
+}
+
+type GetCollectionResponse struct {
+	BookmarkCollection
+	Items []CollectionItem `json:"items"`
+	// Only returned to the owner and collaborators, not on share links.
+	Collaborators []CollectionCollaborator `json:"collaborators,omitempty"`
+}
+
+type CollectionRequest struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+type CollectionItemRequest struct {
+	Note string `json:"note" validate:"max=1000"`
+}
+
+type CollaboratorRequest struct {
+	Username string `json:"username" validate:"required"`
+}
+
+type ShareCollectionResponse struct {
+	Token string `json:"token"`
+}
+
+type CollectionRepository interface {
+	CreateCollection(ownerID, name string) (*BookmarkCollection, error)
+	// GetCollections returns the collections the user owns or collaborates on.
+	GetCollections(userID string) ([]BookmarkCollection, error)
+	GetCollection(id string) (*BookmarkCollection, error)
+	RenameCollection(id, name string) error
+	DeleteCollection(id string) error
+	SetShareNonce(id string, nonce *string) error
+	// GetCollectionItems sets IsBookmarked for viewerID, which may be empty.
+	// Drafts and archived listings are left out unless viewerID owns them.
+	GetCollectionItems(id, viewerID string) ([]CollectionItem, error)
+	// SaveCollectionItem adds the listing or updates its note.
+	SaveCollectionItem(collectionID, listingID, userID, note string) error
+	RemoveCollectionItem(collectionID, listingID string) error
+	GetCollaborators(collectionID string) ([]CollectionCollaborator, error)
+	IsCollaborator(collectionID, userID string) (bool, error)
+	AddCollaborator(collectionID, userID string) error
+	RemoveCollaborator(collectionID, userID string) error
+}
+
+var (
+	ErrCollectionNotFound     = errors.New("collection not found")
+	ErrDuplicateCollection    = errors.New("a collection with this name already exists")
+	ErrCollectionItemNotFound = errors.New("listing is not in this collection")
+	ErrInvalidShareToken      = errors.New("invalid or revoked share link")
+	ErrCollaboratorIsOwner    = errors.New("the owner cannot be added as a collaborator")
+	ErrCollaboratorNotFound   = errors.New("collaborator not found")
+)
@@ -0,0 +1,10 @@
+package domain
+
+import "context"
+
+// JobLock keeps a background job to one server instance at a time.
+type JobLock interface {
+	// TryLock reports whether it took the lock for name. When it did, release
+	// must be called once the job is done.
+	TryLock(ctx context.Context, name string) (release func(), locked bool, err error)
+}
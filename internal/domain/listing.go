@@ -1,6 +1,10 @@
 package domain
 
-import "time"
+import (
+	"errors"
+	"slices"
+	"time"
+)
 
 type Listing struct {
 	ID                 string    `json:"id"`
@@ -9,6 +13,8 @@ type Listing struct {
 	Type               string    `json:"type"`
 	Price              int       `json:"price"`
 	Location           string    `json:"location"`
+	Latitude           *float64  `json:"latitude" validate:"required_with=Longitude,omitempty,gte=-90,lte=90"`
+	Longitude          *float64  `json:"longitude" validate:"required_with=Latitude,omitempty,gte=-180,lte=180"`
 	Bathrooms          int       `json:"bathrooms"`
 	Bedrooms           int       `json:"bedrooms"`
 	CreatedAt          time.Time `json:"created_at"`
@@ -21,6 +27,9 @@ type Listing struct {
 	IsPoolAvailable    bool      `json:"is_pool_available"`
 	IsWasherAvailable  bool      `json:"is_washer_available"`
 	IsWifiAvailable    bool      `json:"is_wifi_available"`
+	// AmenityIDs replaces the listing's amenities when present. The is_*
+	// fields are kept for older clients and follow the catalog.
+	AmenityIDs []string `json:"amenity_ids" validate:"omitempty,dive,uuid"`
 }
 
 type CreateListingRequest struct {
@@ -30,6 +39,8 @@ type CreateListingRequest struct {
 	Type               string   `json:"type"`
 	Price              int      `json:"price"`
 	Location           string   `json:"location"`
+	Latitude           *float64 `json:"latitude" validate:"required_with=Longitude,omitempty,gte=-90,lte=90"`
+	Longitude          *float64 `json:"longitude" validate:"required_with=Latitude,omitempty,gte=-180,lte=180"`
 	Bathrooms          int      `json:"bathrooms"`
 	Bedrooms           int      `json:"bedrooms"`
 	ImageKeys          []string `json:"image_keys"`
@@ -42,42 +53,254 @@ type CreateListingRequest struct {
 	IsWasherAvailable  bool     `json:"is_washer_available"`
 	IsWifiAvailable    bool     `json:"is_wifi_available"`
 	UserID             string   `json:"user_id"`
+	AmenityIDs         []string `json:"amenity_ids" validate:"omitempty,dive,uuid"`
+	// Status is optional and defaults to published.
+	Status string `json:"status" validate:"omitempty,oneof=draft published"`
 }
 
 type GetListingDetailsResponse struct {
-	ID                 string    `json:"id"`
-	Title              string    `json:"title"`
-	Description        string    `json:"description"`
-	Type               string    `json:"type"`
-	Price              int       `json:"price"`
-	Location           string    `json:"location"`
-	Bathrooms          int       `json:"bathrooms"`
-	Bedrooms           int       `json:"bedrooms"`
-	CreatedAt          time.Time `json:"created_at"`
-	ImageKeys          []string  `json:"image_keys"`
-	IsAirConditioned   bool      `json:"is_air_conditioned"`
-	IsBalconyAvailable bool      `json:"is_balcony_available"`
-	IsDryerAvailable   bool      `json:"is_dryer_available"`
-	IsHeated           bool      `json:"is_heated"`
-	IsParkingAvailable bool      `json:"is_parking_available"`
-	IsPoolAvailable    bool      `json:"is_pool_available"`
-	IsWasherAvailable  bool      `json:"is_washer_available"`
-	IsWifiAvailable    bool      `json:"is_wifi_available"`
-	UserID             string    `json:"user_id"`
+	ID                 string     `json:"id"`
+	Title              string     `json:"title"`
+	Description        string     `json:"description"`
+	Type               string     `json:"type"`
+	Price              int        `json:"price"`
+	Location           string     `json:"location"`
+	Latitude           *float64   `json:"latitude"`
+	Longitude          *float64   `json:"longitude"`
+	Bathrooms          int        `json:"bathrooms"`
+	Bedrooms           int        `json:"bedrooms"`
+	CreatedAt          time.Time  `json:"created_at"`
+	ImageKeys          []string   `json:"image_keys"`
+	IsAirConditioned   bool       `json:"is_air_conditioned"`
+	IsBalconyAvailable bool       `json:"is_balcony_available"`
+	IsDryerAvailable   bool       `json:"is_dryer_available"`
+	IsHeated           bool       `json:"is_heated"`
+	IsParkingAvailable bool       `json:"is_parking_available"`
+	IsPoolAvailable    bool       `json:"is_pool_available"`
+	IsWasherAvailable  bool       `json:"is_washer_available"`
+	IsWifiAvailable    bool       `json:"is_wifi_available"`
+	UserID             string     `json:"user_id"`
+	Version            int        `json:"version"`
+	Status             string     `json:"status"`
+	PreviousPrice      *int       `json:"previous_price"`
+	PriceDroppedAt     *time.Time `json:"price_dropped_at"`
+	Amenities          []Amenity  `json:"amenities"`
+	IsBookmarked       bool       `json:"is_bookmarked"`
+	BookmarkCount      int        `json:"bookmark_count"`
+
+	// Rating covers the reviews left from this listing, OwnerRating every
+	// review of its owner.
+	Rating      RatingSummary `json:"rating"`
+	OwnerRating RatingSummary `json:"owner_rating"`
 }
+
+// UpdateListingRequest is the body of PATCH /listing/:id. Nil fields are left
+// unchanged.
+type UpdateListingRequest struct {
+	Title              *string   `json:"title" validate:"omitempty,min=1,max=200"`
+	Description        *string   `json:"description" validate:"omitempty,min=1,max=5000"`
+	Type               *string   `json:"type" validate:"omitempty,min=1"`
+	Price              *int      `json:"price" validate:"omitempty,gt=0"`
+	Location           *string   `json:"location" validate:"omitempty,min=1"`
+	Latitude           *float64  `json:"latitude" validate:"required_with=Longitude,omitempty,gte=-90,lte=90"`
+	Longitude          *float64  `json:"longitude" validate:"required_with=Latitude,omitempty,gte=-180,lte=180"`
+	Bathrooms          *int      `json:"bathrooms" validate:"omitempty,gte=0"`
+	Bedrooms           *int      `json:"bedrooms" validate:"omitempty,gte=0"`
+	ImageKeys          *[]string `json:"image_keys" validate:"omitempty,min=1"`
+	IsAirConditioned   *bool     `json:"is_air_conditioned"`
+	IsBalconyAvailable *bool     `json:"is_balcony_available"`
+	IsDryerAvailable   *bool     `json:"is_dryer_available"`
+	IsHeated           *bool     `json:"is_heated"`
+	IsParkingAvailable *bool     `json:"is_parking_available"`
+	IsPoolAvailable    *bool     `json:"is_pool_available"`
+	IsWasherAvailable  *bool     `json:"is_washer_available"`
+	IsWifiAvailable    *bool     `json:"is_wifi_available"`
+	AmenityIDs         *[]string `json:"amenity_ids" validate:"omitempty,dive,uuid"`
+}
+
 type GetListingsResponse struct {
-	Listings []ListingInfo `json:"listings"`
+	Listings   []ListingInfo `json:"listings"`
+	Total      int           `json:"total"`
+	Page       int           `json:"page"`
+	Limit      int           `json:"limit"`
+	TotalPages int           `json:"total_pages"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+type GetBookmarkedListingsResponse struct {
+	Listings   []ListingInfo `json:"listings"`
+	NextCursor string        `json:"next_cursor,omitempty"`
 }
 
 type ListingInfo struct {
-	ID        string   `json:"id"`
-	Title     string   `json:"title"`
-	Type      string   `json:"type"`
-	Price     int      `json:"price"`
-	Location  string   `json:"location"`
-	Bathrooms int      `json:"bathrooms"`
-	Bedrooms  int      `json:"bedrooms"`
-	ImageKeys []string `json:"image_keys"`
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	Type      string    `json:"type"`
+	Price     int       `json:"price"`
+	Location  string    `json:"location"`
+	Bathrooms int       `json:"bathrooms"`
+	Bedrooms  int       `json:"bedrooms"`
+	ImageKeys []string  `json:"image_keys"`
+	CreatedAt time.Time `json:"created_at"`
+	Latitude  *float64  `json:"latitude"`
+	Longitude *float64  `json:"longitude"`
+	Status    string    `json:"status"`
+	// PreviousPrice is the price before the last change; PriceDroppedAt is
+	// set while the current price is a reduction.
+	PreviousPrice  *int       `json:"previous_price"`
+	PriceDroppedAt *time.Time `json:"price_dropped_at"`
+	Amenities      []Amenity  `json:"amenities"`
+	// IsBookmarked is always false for anonymous requests.
+	IsBookmarked  bool `json:"is_bookmarked"`
+	BookmarkCount int  `json:"bookmark_count"`
+	// Only set when lat and lng are given.
+	DistanceKm *float64 `json:"distance_km,omitempty"`
+	// Only set when searching with q. TitleHighlight and Snippet are
+	// HTML-escaped, with matches wrapped in <mark>.
+	Rank           float32 `json:"rank,omitempty"`
+	TitleHighlight string  `json:"title_highlight,omitempty"`
+	Snippet        string  `json:"snippet,omitempty"`
+}
+
+const (
+	DefaultListingsLimit = 20
+	MaxListingsLimit     = 100
+)
+
+// ListingFilter holds the query parameters accepted by GET /listing.
+// Nil pointers and empty strings mean the filter is not applied. The JSON
+// form is what saved searches store, so paging and sorting are left out.
+type ListingFilter struct {
+	Q                  string `form:"q" json:"q,omitempty" validate:"max=200"`
+	Type               string `form:"type" json:"type,omitempty"`
+	MinPrice           *int   `form:"min_price" json:"min_price,omitempty" validate:"omitempty,gte=0"`
+	MaxPrice           *int   `form:"max_price" json:"max_price,omitempty" validate:"omitempty,gte=0"`
+	MinBedrooms        *int   `form:"min_bedrooms" json:"min_bedrooms,omitempty" validate:"omitempty,gte=0"`
+	MinBathrooms       *int   `form:"min_bathrooms" json:"min_bathrooms,omitempty" validate:"omitempty,gte=0"`
+	Location           string `form:"location" json:"location,omitempty"`
+	IsAirConditioned   *bool  `form:"is_air_conditioned" json:"is_air_conditioned,omitempty"`
+	IsBalconyAvailable *bool  `form:"is_balcony_available" json:"is_balcony_available,omitempty"`
+	IsDryerAvailable   *bool  `form:"is_dryer_available" json:"is_dryer_available,omitempty"`
+	IsHeated           *bool  `form:"is_heated" json:"is_heated,omitempty"`
+	IsParkingAvailable *bool  `form:"is_parking_available" json:"is_parking_available,omitempty"`
+	IsPoolAvailable    *bool  `form:"is_pool_available" json:"is_pool_available,omitempty"`
+	IsWasherAvailable  *bool  `form:"is_washer_available" json:"is_washer_available,omitempty"`
+	IsWifiAvailable    *bool  `form:"is_wifi_available" json:"is_wifi_available,omitempty"`
+	// Amenities holds catalog IDs; listings must have all of them.
+	Amenities []string `form:"amenities" json:"amenities,omitempty" validate:"omitempty,dive,uuid"`
+	// BBox is "min_lng,min_lat,max_lng,max_lat".
+	BBox     string       `form:"bbox" json:"bbox,omitempty"`
+	Bounds   *BoundingBox `form:"-" json:"-"`
+	Lat      *float64     `form:"lat" json:"lat,omitempty" validate:"required_with=Lng,omitempty,gte=-90,lte=90"`
+	Lng      *float64     `form:"lng" json:"lng,omitempty" validate:"required_with=Lat,omitempty,gte=-180,lte=180"`
+	RadiusKm *float64     `form:"radius_km" json:"radius_km,omitempty" validate:"omitempty,gt=0,lte=500"`
+	Sort     string       `form:"sort" json:"-" validate:"omitempty,oneof=price created_at relevance distance"`
+	Order    string       `form:"order" json:"-" validate:"omitempty,oneof=asc desc"`
+	Page     int          `form:"page" json:"-" validate:"omitempty,gte=1"`
+	Limit    int          `form:"limit" json:"-" validate:"omitempty,gte=1,lte=100"`
+	// Cursor switches from offset to keyset paging. It is only valid when
+	// sorting by created_at, which is the default unless q is set.
+	Cursor string  `form:"cursor" json:"-"`
+	After  *Cursor `form:"-" json:"-"`
+	// AvailableFrom and AvailableTo are check-in and check-out dates; only
+	// rentals free for the whole stay are returned.
+	AvailableFrom string `form:"available_from" json:"available_from,omitempty" validate:"required_with=AvailableTo,omitempty,datetime=2006-01-02"`
+	AvailableTo   string `form:"available_to" json:"available_to,omitempty" validate:"required_with=AvailableFrom,omitempty,datetime=2006-01-02"`
+	// Set by the use case, never from the query string.
+	Statuses        []string   `form:"-" json:"-"`
+	OwnerID         string     `form:"-" json:"-"`
+	ViewerID        string     `form:"-" json:"-"`
+	PublishedAfter  *time.Time `form:"-" json:"-"`
+	PublishedBefore *time.Time `form:"-" json:"-"`
+}
+
+const (
+	ListingStatusDraft      = "draft"
+	ListingStatusPublished  = "published"
+	ListingStatusUnderOffer = "under_offer"
+	ListingStatusSold       = "sold"
+	ListingStatusRented     = "rented"
+	ListingStatusArchived   = "archived"
+)
+
+// PublicListingStatuses are the statuses shown to everyone; drafts and
+// archived listings are only visible to their owner.
+var PublicListingStatuses = []string{
+	ListingStatusPublished,
+	ListingStatusUnderOffer,
+	ListingStatusSold,
+	ListingStatusRented,
+}
+
+// IsVisibleTo reports whether viewer may see the listing. Viewer is nil for
+// anonymous requests.
+func (l *GetListingDetailsResponse) IsVisibleTo(viewer *Actor) bool {
+	return slices.Contains(PublicListingStatuses, l.Status) || (viewer != nil && viewer.CanModify(l.UserID))
+}
+
+// ActiveListingStatuses are the statuses of listings still on the market.
+var ActiveListingStatuses = []string{
+	ListingStatusPublished,
+	ListingStatusUnderOffer,
+}
+
+type BoundingBox struct {
+	MinLng float64
+	MinLat float64
+	MaxLng float64
+	MaxLat float64
+}
+
+const DefaultNearbyRadiusKm = 5.0
+
+var (
+	ErrListingNotFound    = errors.New("listing not found")
+	ErrVersionConflict    = errors.New("listing was modified by another request")
+	ErrNothingToUpdate    = errors.New("no fields to update")
+	ErrInvalidTransition  = errors.New("listing status change not allowed")
+	ErrInvalidBoundingBox = errors.New("invalid bbox, expected min_lng,min_lat,max_lng,max_lat")
+	ErrMissingCoordinates = errors.New("lat and lng are required")
+	ErrBookmarkNotFound   = errors.New("bookmark not found")
+	ErrIncompleteDates    = errors.New("available_from and available_to must be given together")
+)
+
+// ListingClusterFilter accepts the same filters as GET /listing plus the map
+// zoom level that decides the grid cell size.
+type ListingClusterFilter struct {
+	ListingFilter
+	Zoom *int `form:"zoom" validate:"required,gte=0,lte=22"`
+}
+
+type ListingCluster struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Count     int     `json:"count"`
+	MinPrice  int     `json:"min_price"`
+	MaxPrice  int     `json:"max_price"`
+}
+
+type ListingPoint struct {
+	ID        string  `json:"id"`
+	Title     string  `json:"title"`
+	Price     int     `json:"price"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+type GetListingClustersResponse struct {
+	Clusters []ListingCluster `json:"clusters"`
+	Points   []ListingPoint   `json:"points"`
+}
+
+type PriceChange struct {
+	OldPrice  int       `json:"old_price"`
+	NewPrice  int       `json:"new_price"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// BookmarkSettingsRequest is the body of PATCH /bookmark/:listing_id.
+type BookmarkSettingsRequest struct {
+	Notify *bool `json:"notify" validate:"required"`
 }
 
 type DeleteListingRequest struct {
@@ -87,10 +310,28 @@ type DeleteListingRequest struct {
 type ListingRepository interface {
 	CreateListing(request *CreateListingRequest) (string, error)
 	GetListingByID(id string) (*GetListingDetailsResponse, error)
-	GetListings() (*GetListingsResponse, error)
-	UpdateListing(listing *Listing) error
+	GetListings(filter *ListingFilter) (*GetListingsResponse, error)
+	GetListingClusters(filter *ListingFilter, cellSize float64) (*GetListingClustersResponse, error)
+	// UpdateListing replaces every field of the listing. When expectedVersion
+	// is set the update only succeeds if it still matches.
+	UpdateListing(listing *Listing, expectedVersion *int) error
+	// PatchListing applies the non-nil fields of the request. When
+	// expectedVersion is set the update only succeeds if it still matches.
+	PatchListing(id string, request *UpdateListingRequest, expectedVersion *int) error
+	UpdateListingStatus(id, status string) error
+	GetPriceHistory(listingID string) ([]PriceChange, error)
 	DeleteListing(id string) error
+	// BookmarkListing is a no-op when the listing is already bookmarked. A
+	// draft or archived listing the user does not own is reported as
+	// ErrListingNotFound.
 	BookmarkListing(userID, listingID string) error
+	IsBookmarked(userID, listingID string) (bool, error)
 	UnbookmarkListing(userID, listingID string) error
-	GetBookmarkedListings(userID string) ([]ListingInfo, error)
+	// GetBookmarkedListings leaves out drafts and archived listings the user
+	// does not own.
+	GetBookmarkedListings(userID string, after *Cursor, limit int) (*GetBookmarkedListingsResponse, error)
+	SetBookmarkNotify(userID, listingID string, notify bool) error
+	// GetBookmarkSubscribers returns the users who bookmarked the listing and
+	// still want notifications about it.
+	GetBookmarkSubscribers(listingID string) ([]string, error)
 }
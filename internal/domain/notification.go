@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	NotificationPriceDrop      = "price_drop"
+	NotificationStatusChange   = "status_change"
+	NotificationListingDeleted = "listing_deleted"
+	NotificationNewMatches     = "new_matches"
+	NotificationReservation    = "reservation"
+)
+
+type Notification struct {
+	ID     string `json:"id"`
+	UserID string `json:"-"`
+	Type   string `json:"type"`
+	// ListingID is nil once the listing has been deleted.
+	ListingID *string    `json:"listing_id"`
+	Title     string     `json:"title"`
+	Body      string     `json:"body"`
+	ReadAt    *time.Time `json:"read_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type GetNotificationsResponse struct {
+	Notifications []Notification `json:"notifications"`
+	UnreadCount   int            `json:"unread_count"`
+	NextCursor    string         `json:"next_cursor,omitempty"`
+}
+
+const (
+	DefaultNotificationsLimit = 20
+	MaxNotificationsLimit     = 100
+)
+
+// NotificationPusher delivers a notification to a user's open WebSocket
+// connection. It reports false when the user is offline.
+type NotificationPusher interface {
+	PushNotification(userID string, notification *Notification) bool
+}
+
+type NotificationRepository interface {
+	// CreateNotifications stores a copy of the notification for every user
+	// and returns the stored rows.
+	CreateNotifications(userIDs []string, notification *Notification) ([]Notification, error)
+	GetNotifications(userID string, after *Cursor, limit int) (*GetNotificationsResponse, error)
+	MarkNotificationRead(userID, id string) error
+	MarkAllNotificationsRead(userID string) error
+}
+
+var ErrNotificationNotFound = errors.New("notification not found")
@@ -0,0 +1,102 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	OfferStatusPending   = "pending"
+	OfferStatusAccepted  = "accepted"
+	OfferStatusRejected  = "rejected"
+	OfferStatusWithdrawn = "withdrawn"
+	OfferStatusExpired   = "expired"
+)
+
+// Offer events are stored in the offer history and double as the
+// MessageResponse.Type of the frames pushed to the room.
+const (
+	OfferEventSubmitted = "offer_submitted"
+	OfferEventCountered = "offer_countered"
+	OfferEventAccepted  = "offer_accepted"
+	OfferEventRejected  = "offer_rejected"
+	OfferEventWithdrawn = "offer_withdrawn"
+	OfferEventExpired   = "offer_expired"
+)
+
+const (
+	DefaultOfferExpiryHours = 72
+	MaxOfferExpiryHours     = 720
+)
+
+// Offer is a negotiation between a customer and a listing owner. Amount,
+// Conditions and ExpiresAt are the terms currently on the table, proposed by
+// whoever is not AwaitingUserID.
+type Offer struct {
+	ID             string       `json:"id"`
+	ListingID      string       `json:"listing_id"`
+	ListingTitle   string       `json:"listing_title"`
+	RoomID         string       `json:"room_id"`
+	CustomerID     string       `json:"customer_id"`
+	OwnerID        string       `json:"owner_id"`
+	Amount         int          `json:"amount"`
+	Conditions     string       `json:"conditions"`
+	ExpiresAt      time.Time    `json:"expires_at"`
+	Status         string       `json:"status"`
+	AwaitingUserID string       `json:"awaiting_user_id"`
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+	History        []OfferEvent `json:"history,omitempty"`
+}
+
+type OfferEvent struct {
+	ID      string `json:"id"`
+	ActorID string `json:"actor_id"`
+	Type    string `json:"type"`
+	// Amount, Conditions and ExpiresAt are only set on submitted and
+	// countered events.
+	Amount     *int       `json:"amount,omitempty"`
+	Conditions string     `json:"conditions,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// OfferRequest is used both to submit an offer and to counter one.
+type OfferRequest struct {
+	Amount     int    `json:"amount" validate:"required,gt=0"`
+	Conditions string `json:"conditions" validate:"max=2000"`
+	// ExpiresInHours defaults to DefaultOfferExpiryHours.
+	ExpiresInHours int `json:"expires_in_hours" validate:"omitempty,gte=1,lte=720"`
+}
+
+type OfferRepository interface {
+	// CreateOffer stores the offer and its submitted event.
+	CreateOffer(offer *Offer) (string, error)
+	GetOffer(id string) (*Offer, error)
+	GetOfferHistory(id string) ([]OfferEvent, error)
+	// GetUserOffers returns the offers the user made or received, newest
+	// first.
+	GetUserOffers(userID string) ([]Offer, error)
+	// CounterOffer replaces the terms of a pending offer awaiting actorID and
+	// hands the turn to awaitingUserID.
+	CounterOffer(id, actorID, awaitingUserID string, req *OfferRequest, expiresAt time.Time) error
+	// CloseOffer moves a pending offer to status and records the event.
+	CloseOffer(id, actorID, status, eventType string) error
+	// AcceptOffer accepts a pending offer awaiting actorID, puts its listing
+	// under offer and rejects the listing's other pending offers in one
+	// transaction. It returns the IDs of the rejected offers.
+	AcceptOffer(id, actorID string) ([]string, error)
+	// ExpireOffers closes every pending offer past its expiry and returns
+	// them.
+	ExpireOffers(now time.Time) ([]Offer, error)
+}
+
+var (
+	ErrOfferNotFound       = errors.New("offer not found")
+	ErrOfferAlreadyPending = errors.New("you already have a pending offer on this listing")
+	ErrOfferClosed         = errors.New("offer is no longer pending")
+	ErrOfferExpired        = errors.New("offer has expired")
+	ErrNotYourTurn         = errors.New("the offer is waiting for the other party")
+	ErrCannotOfferOwn      = errors.New("you cannot make an offer on your own listing")
+	ErrListingNotOfferable = errors.New("listing is not open for offers")
+)
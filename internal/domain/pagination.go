@@ -0,0 +1,21 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// Cursor is a decoded keyset position. Rows are ordered by (created_at, id)
+// so that rows inserted while a client is paging are neither skipped nor
+// repeated.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+type CursorQuery struct {
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit" validate:"omitempty,gte=1,lte=100"`
+}
+
+var ErrInvalidCursor = errors.New("invalid cursor")
@@ -0,0 +1,121 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+const ListingTypeRent = "rent"
+
+// DateLayout is the format of calendar dates in requests and responses.
+const DateLayout = "2006-01-02"
+
+const (
+	// RentalPeriodNightly listings are priced per night.
+	RentalPeriodNightly = "nightly"
+	// RentalPeriodMonthly listings are priced per 30 nights and can't be
+	// booked for less.
+	RentalPeriodMonthly = "monthly"
+
+	MonthlyRentalNights = 30
+	DefaultCalendarDays = 60
+	MaxCalendarDays     = 366
+)
+
+const (
+	ReservationStatusPending   = "pending"
+	ReservationStatusConfirmed = "confirmed"
+	ReservationStatusDeclined  = "declined"
+	ReservationStatusCancelled = "cancelled"
+)
+
+// CalendarDate is an owner's override for one night. A nil Price keeps the
+// listing's own rate.
+type CalendarDate struct {
+	Date      time.Time
+	IsBlocked bool
+	Price     *int
+}
+
+type CalendarDay struct {
+	Date        string `json:"date"`
+	Price       int    `json:"price"`
+	IsBlocked   bool   `json:"is_blocked"`
+	IsReserved  bool   `json:"is_reserved"`
+	IsAvailable bool   `json:"is_available"`
+}
+
+type GetCalendarResponse struct {
+	ListingID string        `json:"listing_id"`
+	Period    string        `json:"period"`
+	Days      []CalendarDay `json:"days"`
+}
+
+// CalendarQuery selects the nights from From up to, but not including, To.
+type CalendarQuery struct {
+	From string `form:"from" validate:"omitempty,datetime=2006-01-02"`
+	To   string `form:"to" validate:"omitempty,datetime=2006-01-02"`
+}
+
+type RentalSettingsRequest struct {
+	Period string `json:"period" validate:"required,oneof=nightly monthly"`
+}
+
+// CalendarDatesRequest sets every night from From up to, but not including,
+// To. Clearing both IsBlocked and Price returns the nights to the defaults.
+type CalendarDatesRequest struct {
+	From      string `json:"from" validate:"required,datetime=2006-01-02"`
+	To        string `json:"to" validate:"required,datetime=2006-01-02"`
+	IsBlocked bool   `json:"is_blocked"`
+	Price     *int   `json:"price" validate:"omitempty,gt=0"`
+}
+
+type Reservation struct {
+	ID           string    `json:"id"`
+	ListingID    string    `json:"listing_id"`
+	ListingTitle string    `json:"listing_title"`
+	CustomerID   string    `json:"customer_id"`
+	CustomerName string    `json:"customer_name"`
+	OwnerID      string    `json:"owner_id"`
+	CheckIn      string    `json:"check_in"`
+	CheckOut     string    `json:"check_out"`
+	Nights       int       `json:"nights"`
+	TotalPrice   int       `json:"total_price"`
+	Status       string    `json:"status"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type ReservationRequest struct {
+	CheckIn  string `json:"check_in" validate:"required,datetime=2006-01-02"`
+	CheckOut string `json:"check_out" validate:"required,datetime=2006-01-02"`
+}
+
+type RentalRepository interface {
+	// GetRentalPeriod returns RentalPeriodNightly until the owner sets one.
+	GetRentalPeriod(listingID string) (string, error)
+	SetRentalPeriod(listingID, period string) error
+	GetCalendarDates(listingID string, from, to time.Time) ([]CalendarDate, error)
+	SetCalendarDates(listingID string, from, to time.Time, isBlocked bool, price *int) error
+	CreateReservation(reservation *Reservation) (string, error)
+	GetReservation(id string) (*Reservation, error)
+	// GetConfirmedReservations returns the confirmed stays overlapping
+	// [from, to).
+	GetConfirmedReservations(listingID string, from, to time.Time) ([]Reservation, error)
+	// GetUserReservations returns reservations made by or for the user.
+	GetUserReservations(userID string) ([]Reservation, error)
+	// UpdateReservationStatus only changes a reservation currently in one of
+	// the given statuses.
+	UpdateReservationStatus(id, status string, currentStatuses []string) error
+}
+
+var (
+	ErrNotRentalListing     = errors.New("listing is not for rent")
+	ErrInvalidDateRange     = errors.New("end date must be after start date and within a year")
+	ErrDatesInPast          = errors.New("dates must not be in the past")
+	ErrDatesUnavailable     = errors.New("some of the dates are not available")
+	ErrStayTooShort         = errors.New("monthly rentals must be booked for at least 30 nights")
+	ErrCannotReserveOwn     = errors.New("you cannot reserve your own listing")
+	ErrReservationNotFound  = errors.New("reservation not found")
+	ErrReservationClosed    = errors.New("reservation can no longer be changed")
+	ErrListingNotReservable = errors.New("listing is not open for reservations")
+)
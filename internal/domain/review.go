@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// MinRoomAgeForReview is how long a customer must have been talking to an
+// owner before they can review them without a completed viewing. Both must
+// have sent at least one message in that time.
+const MinRoomAgeForReview = 7 * 24 * time.Hour
+
+// Review is a customer's rating of a listing owner, left from the room they
+// talked in.
+type Review struct {
+	ID           string     `json:"id"`
+	ListingID    string     `json:"listing_id"`
+	ListingTitle string     `json:"listing_title"`
+	OwnerID      string     `json:"owner_id"`
+	AuthorID     string     `json:"author_id"`
+	AuthorName   string     `json:"author_name"`
+	Rating       int        `json:"rating"`
+	Text         string     `json:"text"`
+	Reply        *string    `json:"reply"`
+	RepliedAt    *time.Time `json:"replied_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// RatingSummary aggregates the reviews of a listing or an owner. Average is
+// 0 when there are no reviews.
+type RatingSummary struct {
+	Average float64 `json:"average"`
+	Count   int     `json:"count"`
+}
+
+type ReviewRequest struct {
+	Rating int    `json:"rating" validate:"required,gte=1,lte=5"`
+	Text   string `json:"text" validate:"required,max=2000"`
+}
+
+type ReviewReplyRequest struct {
+	Text string `json:"text" validate:"required,max=2000"`
+}
+
+type GetUserReviewsResponse struct {
+	Rating  RatingSummary `json:"rating"`
+	Reviews []Review      `json:"reviews"`
+}
+
+type ReviewRepository interface {
+	// CreateReview fails with ErrAlreadyReviewed if the room has a review.
+	CreateReview(review *Review, roomID string) (string, error)
+	GetReview(id string) (*Review, error)
+	// ReplyToReview fails with ErrAlreadyReplied if the review has a reply.
+	ReplyToReview(id, text string) error
+	GetListingReviews(listingID string) ([]Review, error)
+	GetOwnerReviews(ownerID string) ([]Review, error)
+	GetOwnerRating(ownerID string) (*RatingSummary, error)
+	// IsRoomReviewable reports whether the room was opened before
+	// openedBefore and both participants have messaged in it, or it has a
+	// viewing that ended before now.
+	IsRoomReviewable(roomID string, openedBefore, now time.Time) (bool, error)
+}
+
+var (
+	ErrReviewNotFound   = errors.New("review not found")
+	ErrReviewNotAllowed = errors.New("you can only review an owner you have talked to for a while or viewed a listing with")
+	ErrAlreadyReviewed  = errors.New("you have already reviewed this listing")
+	ErrAlreadyReplied   = errors.New("this review already has a reply")
+)
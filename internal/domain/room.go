@@ -1,5 +1,10 @@
 package domain
 
+import (
+	"errors"
+	"time"
+)
+
 type Room struct {
 	RoomID       string `json:"room_id"`
 	PropertyID   string `json:"property_id"`
@@ -11,39 +16,115 @@ type Room struct {
 	CustomerName string `json:"customer_name"`
 }
 
+// AuthMessage is the first frame of a connection using the deprecated legacy
+// WebSocket authentication.
 type AuthMessage struct {
 	Type   string `json:"type"`
 	UserID string `json:"user_id"`
 }
 
+// ChatMessage is a frame sent by the client. Type is empty for chat messages
+// and "read" for a read frame, which only needs RoomID and MessageID.
 type ChatMessage struct {
+	Type       string `json:"type"`
 	Text       string `json:"text"`
 	ReceiverID string `json:"receiver_id"`
 	SenderID   string `json:"sender_id"`
 	RoomID     string `json:"room_id"`
+	MessageID  string `json:"message_id"`
 }
 
 type MessageResponse struct {
-	Type      string `json:"type"`
-	Text      string `json:"text,omitempty"`
-	SenderID  string `json:"sender_id,omitempty"`
-	RoomID    string `json:"room_id,omitempty"`
-	Status    string `json:"status,omitempty"`
-	Error     string `json:"error,omitempty"`
-	Timestamp int64  `json:"timestamp,omitempty"`
+	// ID is the stored message's ID on frames about a saved message, so the
+	// receiver can mark it read.
+	ID           string        `json:"id,omitempty"`
+	Type         string        `json:"type"`
+	Text         string        `json:"text,omitempty"`
+	SenderID     string        `json:"sender_id,omitempty"`
+	RoomID       string        `json:"room_id,omitempty"`
+	Status       string        `json:"status,omitempty"`
+	Error        string        `json:"error,omitempty"`
+	Timestamp    int64         `json:"timestamp,omitempty"`
+	Notification *Notification `json:"notification,omitempty"`
+	Offer        *Offer        `json:"offer,omitempty"`
+	ConnectionID string        `json:"connection_id,omitempty"`
+	ReadReceipt  *ReadReceipt  `json:"read_receipt,omitempty"`
+}
+
+// MarkReadRequest marks every message up to and including MessageID as read
+// by the caller. Their own messages are left alone. RoomID comes from the
+// path or the WebSocket frame.
+type MarkReadRequest struct {
+	RoomID    string `json:"-" validate:"required,uuid"`
+	MessageID string `json:"message_id" validate:"required,uuid"`
+}
+
+// ReadReceipt tells the participants how far ReaderID has read. ReadAt is nil
+// when nothing new was marked.
+type ReadReceipt struct {
+	RoomID    string     `json:"room_id"`
+	ReaderID  string     `json:"reader_id"`
+	MessageID string     `json:"message_id"`
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+}
+
+// WSSession is one of a user's live WebSocket connections.
+type WSSession struct {
+	ID          string    `json:"id"`
+	UserAgent   string    `json:"user_agent"`
+	ConnectedAt time.Time `json:"connected_at"`
 }
 
+type GetMessagesResponse struct {
+	Messages   []map[string]any `json:"messages"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+}
+
+const (
+	DefaultMessagesLimit = 50
+	MaxMessagesLimit     = 100
+)
+
 type CreateChatRoomRequest struct {
 	PropertyID string `json:"property_id" validate:"required"`
 	OwnerID    string `json:"owner_id" validate:"required"`
 	CustomerID string `json:"-"`
 }
 
+const (
+	MessageTypeText = "text"
+	// MessageTypeSystem marks messages the server posts on a user's behalf,
+	// such as viewing bookings.
+	MessageTypeSystem = "system"
+	// MessageTypeReadReceipt frames are only pushed live, never stored.
+	MessageTypeReadReceipt = "read_receipt"
+)
+
+// ChatFrameRead is the ChatMessage type of a read frame.
+const ChatFrameRead = "read"
+
+// MessagePusher delivers a frame to a user's live connection and reports
+// whether they were online.
+type MessagePusher interface {
+	PushMessage(userID string, message *MessageResponse) bool
+}
+
 type RoomRepository interface {
 	CreateRoom(propertyID, ownerID, ownerName, customerID, customerName, title, image string) (string, error)
 	CheckRoomExists(roomID string) (bool, error)
 	GetRooms(customerID string) ([]Room, error)
-	SaveMessage(text, senderID, senderName, roomID string) error
+	GetRoomByListingAndCustomer(propertyID, customerID string) (*Room, error)
+	// SaveMessage returns the ID of the stored message.
+	SaveMessage(messageType, text, senderID, senderName, roomID string, offerID *string) (string, error)
 	CheckUserInRoom(userID, roomID string) (bool, error)
-	GetMessagesForRoom(roomID string) ([]map[string]any, error)
+	GetMessagesForRoom(roomID string, before *Cursor, limit int) (*GetMessagesResponse, error)
+	GetRoom(roomID string) (*Room, error)
+	// MarkMessagesRead returns when the messages were marked, or nil if none
+	// were unread.
+	MarkMessagesRead(roomID, readerID, messageID string) (*time.Time, error)
 }
+
+var (
+	ErrRoomNotFound    = errors.New("room not found")
+	ErrMessageNotFound = errors.New("message not found")
+)
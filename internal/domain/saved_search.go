@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	SavedSearchInstant = "instant"
+	SavedSearchDaily   = "daily"
+)
+
+type SavedSearch struct {
+	ID        string        `json:"id"`
+	UserID    string        `json:"-"`
+	Name      string        `json:"name"`
+	Filter    ListingFilter `json:"filter"`
+	Frequency string        `json:"frequency"`
+	// LastCheckedAt is the point up to which new listings have been
+	// reported.
+	LastCheckedAt time.Time `json:"last_checked_at"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type SavedSearchRequest struct {
+	Name      string        `json:"name" validate:"required,max=100"`
+	Filter    ListingFilter `json:"filter"`
+	Frequency string        `json:"frequency" validate:"required,oneof=instant daily"`
+}
+
+// SavedSearchMatchLimit caps how many new listings are named in one alert.
+const SavedSearchMatchLimit = 5
+
+type SavedSearchRepository interface {
+	// CreateSavedSearch marks the new search checked at the database's
+	// current time, the clock published_at is stamped with.
+	CreateSavedSearch(userID string, req *SavedSearchRequest) (*SavedSearch, error)
+	GetSavedSearches(userID string) ([]SavedSearch, error)
+	GetSavedSearch(userID, id string) (*SavedSearch, error)
+	UpdateSavedSearch(userID, id string, req *SavedSearchRequest) (*SavedSearch, error)
+	DeleteSavedSearch(userID, id string) error
+	// GetDueSavedSearches returns every instant search, and the daily
+	// searches last checked at least a day ago, along with the database's
+	// current time. Alerts use that time as the end of their window so it
+	// is on the same clock as published_at.
+	GetDueSavedSearches() ([]SavedSearch, time.Time, error)
+	MarkSavedSearchChecked(id string, checkedAt time.Time) error
+}
+
+var ErrSavedSearchNotFound = errors.New("saved search not found")
@@ -1,5 +1,7 @@
 package domain
 
+import "time"
+
 type UpdateUserRequest struct {
 	FullName  string `json:"full_name" binding:"required"`
 	AvatarKey string `json:"avatar_key"`
@@ -7,6 +9,23 @@ type UpdateUserRequest struct {
 	Email     string `json:"-"`
 }
 
+// PublicProfile is what anyone can see about a user. It must never carry
+// contact details or credentials.
+type PublicProfile struct {
+	ID          string        `json:"id"`
+	Username    string        `json:"username"`
+	FullName    string        `json:"full_name"`
+	AvatarKey   string        `json:"avatar_key"`
+	MemberSince time.Time     `json:"member_since"`
+	Rating      RatingSummary `json:"rating"`
+	// ResponseRate is the percentage of conversations started by a customer
+	// that the user replied to, or nil if there are none yet.
+	ResponseRate   *int          `json:"response_rate"`
+	ActiveListings []ListingInfo `json:"active_listings"`
+}
+
 type UserRepository interface {
 	UpdateUser(name, avatarURL string, userID string) error
+	// GetPublicProfile fills in everything but Rating and ActiveListings.
+	GetPublicProfile(username string) (*PublicProfile, error)
 }
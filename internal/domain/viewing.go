@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	ViewingStatusBooked    = "booked"
+	ViewingStatusCancelled = "cancelled"
+	// ViewingStatusCompleted is never stored: booked viewings are reported as
+	// completed once their slot has ended.
+	ViewingStatusCompleted = "completed"
+)
+
+type ViewingSlot struct {
+	ID        string    `json:"id"`
+	ListingID string    `json:"listing_id"`
+	StartsAt  time.Time `json:"starts_at"`
+	EndsAt    time.Time `json:"ends_at"`
+	IsBooked  bool      `json:"is_booked"`
+}
+
+type ViewingSlotRequest struct {
+	StartsAt time.Time `json:"starts_at" validate:"required"`
+	EndsAt   time.Time `json:"ends_at" validate:"required,gtfield=StartsAt"`
+}
+
+type Viewing struct {
+	ID              string    `json:"id"`
+	SlotID          string    `json:"slot_id"`
+	ListingID       string    `json:"listing_id"`
+	ListingTitle    string    `json:"listing_title"`
+	ListingLocation string    `json:"listing_location"`
+	CustomerID      string    `json:"customer_id"`
+	CustomerName    string    `json:"customer_name"`
+	OwnerID         string    `json:"owner_id"`
+	OwnerName       string    `json:"owner_name"`
+	RoomID          string    `json:"room_id"`
+	StartsAt        time.Time `json:"starts_at"`
+	EndsAt          time.Time `json:"ends_at"`
+	Status          string    `json:"status"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+type RescheduleViewingRequest struct {
+	SlotID string `json:"slot_id" validate:"required,uuid"`
+}
+
+type ViewingRepository interface {
+	CreateSlot(listingID string, req *ViewingSlotRequest) (*ViewingSlot, error)
+	// GetSlots returns the listing's slots that start after from.
+	GetSlots(listingID string, from time.Time) ([]ViewingSlot, error)
+	GetSlot(id string) (*ViewingSlot, error)
+	DeleteSlot(id string) error
+	CreateViewing(slotID, listingID, customerID, ownerID, roomID string) (string, error)
+	GetViewing(id string) (*Viewing, error)
+	RescheduleViewing(id, slotID string) error
+	CancelViewing(id string) error
+	// GetUpcomingViewings returns the booked viewings, as customer or owner,
+	// that end after from.
+	GetUpcomingViewings(userID string, from time.Time) ([]Viewing, error)
+}
+
+var (
+	ErrSlotNotFound         = errors.New("viewing slot not found")
+	ErrDuplicateSlot        = errors.New("a slot already starts at this time")
+	ErrSlotAlreadyBooked    = errors.New("viewing slot is already booked")
+	ErrSlotInPast           = errors.New("viewing slot has already started")
+	ErrViewingNotFound      = errors.New("viewing not found")
+	ErrViewingNotActive     = errors.New("viewing is no longer booked")
+	ErrViewingUnavailable   = errors.New("listing is not open for viewings")
+	ErrCannotBookOwnListing = errors.New("you cannot book a viewing of your own listing")
+)
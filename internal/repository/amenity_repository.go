@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"message-server/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type amenityRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewAmenityRepository(pool *pgxpool.Pool) domain.AmenityRepository {
+	return &amenityRepository{pool: pool}
+}
+
+func (r *amenityRepository) CreateAmenity(req *domain.AmenityRequest) (string, error) {
+	query := `
+		INSERT INTO amenities (name, icon, category)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`
+
+	var id string
+	err := r.pool.QueryRow(context.Background(), query, req.Name, req.Icon, req.Category).Scan(&id)
+	if isUniqueViolation(err) {
+		return "", domain.ErrDuplicateAmenity
+	}
+	return id, err
+}
+
+func (r *amenityRepository) GetAmenities() ([]domain.Amenity, error) {
+	query := `SELECT id, name, icon, category FROM amenities ORDER BY category, name`
+
+	rows, err := r.pool.Query(context.Background(), query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	amenities := []domain.Amenity{}
+	for rows.Next() {
+		var amenity domain.Amenity
+		if err := rows.Scan(&amenity.ID, &amenity.Name, &amenity.Icon, &amenity.Category); err != nil {
+			return nil, err
+		}
+		amenities = append(amenities, amenity)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return amenities, nil
+}
+
+func (r *amenityRepository) UpdateAmenity(id string, req *domain.AmenityRequest) error {
+	query := `UPDATE amenities SET name = $1, icon = $2, category = $3 WHERE id = $4`
+
+	tag, err := r.pool.Exec(context.Background(), query, req.Name, req.Icon, req.Category, id)
+	if isUniqueViolation(err) {
+		return domain.ErrDuplicateAmenity
+	}
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrAmenityNotFound
+	}
+	return nil
+}
+
+// DeleteAmenity refuses amenities mapped to a legacy column, which the
+// Is*Available booleans are kept in sync with.
+func (r *amenityRepository) DeleteAmenity(id string) error {
+	query := `
+		WITH target AS (
+			SELECT id, legacy_column FROM amenities WHERE id = $1
+		), deleted AS (
+			DELETE FROM amenities WHERE id IN (SELECT id FROM target WHERE legacy_column IS NULL)
+		)
+		SELECT legacy_column IS NOT NULL FROM target
+	`
+
+	var legacy bool
+	err := r.pool.QueryRow(context.Background(), query, id).Scan(&legacy)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.ErrAmenityNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if legacy {
+		return domain.ErrLegacyAmenity
+	}
+	return nil
+}
@@ -2,8 +2,11 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"message-server/internal/domain"
+	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -28,10 +31,13 @@ func (r *authRepository) CreateUser(name, username, email, password string) erro
 }
 
 func (r *authRepository) GetUserByUsername(username string) (*domain.User, error) {
-	query := `SELECT id, full_name, username, email, password, avatar_key FROM users WHERE username = $1`
+	query := `SELECT id, full_name, username, email, password, avatar_key, role FROM users WHERE username = $1`
 	var user domain.User
 	err := r.pool.QueryRow(context.Background(), query, username).Scan(&user.ID,
-		&user.FullName, &user.Username, &user.Email, &user.Password, &user.AvatarKey)
+		&user.FullName, &user.Username, &user.Email, &user.Password, &user.AvatarKey, &user.Role)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrUserNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -39,10 +45,10 @@ func (r *authRepository) GetUserByUsername(username string) (*domain.User, error
 }
 
 func (r *authRepository) GetUserByEmail(email string) (*domain.User, error) {
-	query := `SELECT id, full_name, username, email, password, avatar_key FROM users WHERE email = $1`
+	query := `SELECT id, full_name, username, email, password, avatar_key, role FROM users WHERE email = $1`
 	var user domain.User
 	err := r.pool.QueryRow(context.Background(), query, email).Scan(&user.ID,
-		&user.FullName, &user.Username, &user.Email, &user.Password, &user.AvatarKey)
+		&user.FullName, &user.Username, &user.Email, &user.Password, &user.AvatarKey, &user.Role)
 	if err != nil {
 		return nil, err
 	}
@@ -50,10 +56,10 @@ func (r *authRepository) GetUserByEmail(email string) (*domain.User, error) {
 }
 
 func (r *authRepository) GetUserByID(id string) (*domain.User, error) {
-	query := `SELECT id, full_name, username, email, password, avatar_key FROM users WHERE id = $1`
+	query := `SELECT id, full_name, username, email, password, avatar_key, role FROM users WHERE id = $1`
 	var user domain.User
 	err := r.pool.QueryRow(context.Background(), query, id).Scan(&user.ID,
-		&user.FullName, &user.Username, &user.Email, &user.Password, &user.AvatarKey)
+		&user.FullName, &user.Username, &user.Email, &user.Password, &user.AvatarKey, &user.Role)
 	if err != nil {
 		return nil, err
 	}
@@ -110,3 +116,27 @@ func (r *authRepository) CheckUserCredentialsExist(username, email string) error
 
 	return nil
 }
+
+// UseWSTicket relies on the primary key, so a ticket replayed against another
+// instance at the same time is still only accepted once.
+func (r *authRepository) UseWSTicket(id string, expiresAt time.Time) error {
+	ctx := context.Background()
+
+	return pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, "DELETE FROM ws_ticket_uses WHERE expires_at < NOW()"); err != nil {
+			return err
+		}
+
+		tag, err := tx.Exec(ctx, `
+			INSERT INTO ws_ticket_uses (id, expires_at) VALUES ($1, $2)
+			ON CONFLICT (id) DO NOTHING
+		`, id, expiresAt)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return domain.ErrTicketUsed
+		}
+		return nil
+	})
+}
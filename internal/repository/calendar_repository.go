@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"message-server/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type calendarRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewCalendarRepository(pool *pgxpool.Pool) domain.CalendarRepository {
+	return &calendarRepository{pool: pool}
+}
+
+func (r *calendarRepository) SetFeedToken(userID, tokenHash string) error {
+	query := `
+		INSERT INTO calendar_feed_tokens (user_id, token_hash)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET token_hash = EXCLUDED.token_hash, created_at = NOW()
+	`
+
+	_, err := r.pool.Exec(context.Background(), query, userID, tokenHash)
+	return err
+}
+
+func (r *calendarRepository) DeleteFeedToken(userID string) error {
+	query := `DELETE FROM calendar_feed_tokens WHERE user_id = $1`
+
+	tag, err := r.pool.Exec(context.Background(), query, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrCalendarFeedNotFound
+	}
+	return nil
+}
+
+func (r *calendarRepository) GetUserIDByFeedToken(tokenHash string) (string, error) {
+	query := `SELECT user_id FROM calendar_feed_tokens WHERE token_hash = $1`
+
+	var userID string
+	err := r.pool.QueryRow(context.Background(), query, tokenHash).Scan(&userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", domain.ErrCalendarFeedNotFound
+	}
+	return userID, err
+}
@@ -0,0 +1,271 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"message-server/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type collectionRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewCollectionRepository(pool *pgxpool.Pool) domain.CollectionRepository {
+	return &collectionRepository{pool: pool}
+}
+
+const collectionColumns = `c.id, c.owner_id, c.name, c.share_nonce, c.created_at,
+	(SELECT COUNT(*) FROM bookmarks b WHERE b.collection_id = c.id)`
+
+func (r *collectionRepository) CreateCollection(ownerID, name string) (*domain.BookmarkCollection, error) {
+	query := `
+		WITH c AS (
+			INSERT INTO bookmark_collections (owner_id, name)
+			VALUES ($1, $2)
+			RETURNING id, owner_id, name, share_nonce, created_at
+		)
+		SELECT c.id, c.owner_id, c.name, c.share_nonce, c.created_at, 0 FROM c
+	`
+
+	collection, err := scanCollection(r.pool.QueryRow(context.Background(), query, ownerID, name))
+	if isUniqueViolation(err) {
+		return nil, domain.ErrDuplicateCollection
+	}
+	return collection, err
+}
+
+func (r *collectionRepository) GetCollections(userID string) ([]domain.BookmarkCollection, error) {
+	query := `
+		SELECT ` + collectionColumns + `
+		FROM bookmark_collections c
+		WHERE c.owner_id = $1 OR EXISTS (
+			SELECT 1 FROM bookmark_collection_members m
+			WHERE m.collection_id = c.id AND m.user_id = $1
+		)
+		ORDER BY c.created_at DESC
+	`
+
+	rows, err := r.pool.Query(context.Background(), query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	collections := []domain.BookmarkCollection{}
+	for rows.Next() {
+		collection, err := scanCollection(rows)
+		if err != nil {
+			return nil, err
+		}
+		collections = append(collections, *collection)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return collections, nil
+}
+
+func (r *collectionRepository) GetCollection(id string) (*domain.BookmarkCollection, error) {
+	query := `SELECT ` + collectionColumns + ` FROM bookmark_collections c WHERE c.id = $1`
+
+	return scanCollection(r.pool.QueryRow(context.Background(), query, id))
+}
+
+func (r *collectionRepository) RenameCollection(id, name string) error {
+	query := `UPDATE bookmark_collections SET name = $1 WHERE id = $2`
+
+	tag, err := r.pool.Exec(context.Background(), query, name, id)
+	if isUniqueViolation(err) {
+		return domain.ErrDuplicateCollection
+	}
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrCollectionNotFound
+	}
+	return nil
+}
+
+func (r *collectionRepository) DeleteCollection(id string) error {
+	query := `DELETE FROM bookmark_collections WHERE id = $1`
+
+	tag, err := r.pool.Exec(context.Background(), query, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrCollectionNotFound
+	}
+	return nil
+}
+
+func (r *collectionRepository) SetShareNonce(id string, nonce *string) error {
+	query := `UPDATE bookmark_collections SET share_nonce = $1 WHERE id = $2`
+
+	tag, err := r.pool.Exec(context.Background(), query, nonce, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrCollectionNotFound
+	}
+	return nil
+}
+
+func (r *collectionRepository) GetCollectionItems(id, viewerID string) ([]domain.CollectionItem, error) {
+	query := `
+		SELECT l.id, l.title, l.type, l.price, l.location, l.bathrooms, l.bedrooms, l.image_keys, l.created_at,
+		l.latitude, l.longitude, l.status, l.previous_price, l.price_dropped_at, ` + amenitiesColumn("l") + `,
+		` + isBookmarkedColumn("l", "$2") + `, ` + bookmarkCountColumn("l") + `,
+		b.note, b.user_id, b.created_at
+		FROM bookmarks b
+		JOIN listings l ON l.id = b.listing_id
+		WHERE b.collection_id = $1 AND ` + visibleToCondition("l", "$2", "$3") + `
+		ORDER BY b.created_at DESC, b.id DESC
+	`
+
+	// An anonymous viewer is passed as NULL, which never matches.
+	var viewer any
+	if viewerID != "" {
+		viewer = viewerID
+	}
+
+	rows, err := r.pool.Query(context.Background(), query, id, viewer, domain.PublicListingStatuses)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []domain.CollectionItem{}
+	for rows.Next() {
+		var item domain.CollectionItem
+		listing := &item.Listing
+		err := rows.Scan(&listing.ID, &listing.Title, &listing.Type, &listing.Price, &listing.Location,
+			&listing.Bathrooms, &listing.Bedrooms, &listing.ImageKeys, &listing.CreatedAt,
+			&listing.Latitude, &listing.Longitude, &listing.Status, &listing.PreviousPrice, &listing.PriceDroppedAt,
+			&listing.Amenities, &listing.IsBookmarked, &listing.BookmarkCount, &item.Note, &item.AddedBy, &item.AddedAt)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (r *collectionRepository) SaveCollectionItem(collectionID, listingID, userID, note string) error {
+	query := `
+		INSERT INTO bookmarks (user_id, listing_id, collection_id, note)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (collection_id, listing_id) WHERE collection_id IS NOT NULL
+		DO UPDATE SET note = EXCLUDED.note
+	`
+
+	_, err := r.pool.Exec(context.Background(), query, userID, listingID, collectionID, note)
+	if isForeignKeyViolation(err) {
+		return domain.ErrListingNotFound
+	}
+	return err
+}
+
+func (r *collectionRepository) RemoveCollectionItem(collectionID, listingID string) error {
+	query := `DELETE FROM bookmarks WHERE collection_id = $1 AND listing_id = $2`
+
+	tag, err := r.pool.Exec(context.Background(), query, collectionID, listingID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrCollectionItemNotFound
+	}
+	return nil
+}
+
+func (r *collectionRepository) GetCollaborators(collectionID string) ([]domain.CollectionCollaborator, error) {
+	query := `
+		SELECT u.id, u.username, u.full_name, m.created_at
+		FROM bookmark_collection_members m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.collection_id = $1
+		ORDER BY m.created_at
+	`
+
+	rows, err := r.pool.Query(context.Background(), query, collectionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	collaborators := []domain.CollectionCollaborator{}
+	for rows.Next() {
+		var collaborator domain.CollectionCollaborator
+		err := rows.Scan(&collaborator.UserID, &collaborator.Username, &collaborator.FullName, &collaborator.AddedAt)
+		if err != nil {
+			return nil, err
+		}
+		collaborators = append(collaborators, collaborator)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return collaborators, nil
+}
+
+func (r *collectionRepository) IsCollaborator(collectionID, userID string) (bool, error) {
+	query := `SELECT EXISTS(SELECT 1 FROM bookmark_collection_members WHERE collection_id = $1 AND user_id = $2)`
+
+	var exists bool
+	err := r.pool.QueryRow(context.Background(), query, collectionID, userID).Scan(&exists)
+	return exists, err
+}
+
+func (r *collectionRepository) AddCollaborator(collectionID, userID string) error {
+	query := `
+		INSERT INTO bookmark_collection_members (collection_id, user_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`
+
+	_, err := r.pool.Exec(context.Background(), query, collectionID, userID)
+	return err
+}
+
+func (r *collectionRepository) RemoveCollaborator(collectionID, userID string) error {
+	query := `DELETE FROM bookmark_collection_members WHERE collection_id = $1 AND user_id = $2`
+
+	tag, err := r.pool.Exec(context.Background(), query, collectionID, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrCollaboratorNotFound
+	}
+	return nil
+}
+
+func scanCollection(row pgx.Row) (*domain.BookmarkCollection, error) {
+	var collection domain.BookmarkCollection
+	err := row.Scan(&collection.ID, &collection.OwnerID, &collection.Name, &collection.ShareNonce,
+		&collection.CreatedAt, &collection.ItemCount)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrCollectionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	collection.IsShared = collection.ShareNonce != nil
+	return &collection, nil
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"message-server/internal/domain"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type jobLock struct {
+	pool *pgxpool.Pool
+}
+
+// NewJobLock uses Postgres advisory locks, so every instance sharing the
+// database agrees on who runs a job.
+func NewJobLock(pool *pgxpool.Pool) domain.JobLock {
+	return &jobLock{pool: pool}
+}
+
+// TryLock holds on to a pooled connection while locked, because advisory
+// locks belong to the session that took them.
+func (l *jobLock) TryLock(ctx context.Context, name string) (func(), bool, error) {
+	conn, err := l.pool.Acquire(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var locked bool
+	if err := conn.QueryRow(ctx, "SELECT pg_try_advisory_lock(hashtext($1))", name).Scan(&locked); err != nil {
+		conn.Release()
+		return nil, false, err
+	}
+	if !locked {
+		conn.Release()
+		return nil, false, nil
+	}
+
+	release := func() {
+		if _, err := conn.Exec(context.Background(), "SELECT pg_advisory_unlock(hashtext($1))", name); err != nil {
+			// The lock may still be held, so the session must not go back
+			// to the pool.
+			conn.Conn().Close(context.Background())
+		}
+		conn.Release()
+	}
+	return release, true, nil
+}
@@ -2,8 +2,15 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math"
 	"message-server/internal/domain"
+	"message-server/pkg"
+	"strings"
+	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -21,16 +28,26 @@ func (r *listingRepository) CreateListing(req *domain.CreateListingRequest) (str
 		(id, title, description, type, price, location, bathrooms, 
 		bedrooms, image_keys, is_air_conditioned, is_balcony_available,
 		is_dryer_available,  is_heated, is_parking_available, 
-		is_pool_available, is_washer_available, is_wifi_available, user_id)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+		is_pool_available, is_washer_available, is_wifi_available, user_id,
+		latitude, longitude, status, published_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21,
+		CASE WHEN $21::text = ANY($22::text[]) THEN NOW() END)
 		RETURNING id
 	`
 
 	var id string
-	err := r.pool.QueryRow(context.Background(), query, req.ID, req.Title, req.Description, req.Type,
-		req.Price, req.Location, req.Bathrooms, req.Bedrooms, req.ImageKeys,
-		req.IsAirConditioned, req.IsBalconyAvailable, req.IsDryerAvailable, req.IsHeated,
-		req.IsParkingAvailable, req.IsPoolAvailable, req.IsWasherAvailable, req.IsWifiAvailable, req.UserID).Scan(&id)
+	err := pgx.BeginFunc(context.Background(), r.pool, func(tx pgx.Tx) error {
+		err := tx.QueryRow(context.Background(), query, req.ID, req.Title, req.Description, req.Type,
+			req.Price, req.Location, req.Bathrooms, req.Bedrooms, req.ImageKeys,
+			req.IsAirConditioned, req.IsBalconyAvailable, req.IsDryerAvailable, req.IsHeated,
+			req.IsParkingAvailable, req.IsPoolAvailable, req.IsWasherAvailable, req.IsWifiAvailable, req.UserID,
+			req.Latitude, req.Longitude, req.Status, domain.PublicListingStatuses).Scan(&id)
+		if err != nil {
+			return err
+		}
+
+		return syncListingAmenities(context.Background(), tx, id, req.AmenityIDs)
+	})
 	return id, err
 }
 
@@ -38,7 +55,11 @@ func (r *listingRepository) GetListingByID(id string) (*domain.GetListingDetails
 	query := `
 		SELECT id, title, description, type, price, location, bathrooms, 
 		bedrooms, image_keys, is_air_conditioned, is_balcony_available, is_dryer_available,
-		is_heated, is_parking_available, is_pool_available, is_washer_available, is_wifi_available, user_id
+		is_heated, is_parking_available, is_pool_available, is_washer_available, is_wifi_available, user_id,
+		latitude, longitude, created_at, version, status, previous_price, price_dropped_at,
+		` + amenitiesColumn("listings") + `, ` + bookmarkCountColumn("listings") + `,
+		` + ratingColumns("listing_id", "listings.id") + `,
+		` + ratingColumns("owner_id", "listings.user_id") + `
 		FROM listings
 		WHERE id = $1
 	`
@@ -49,44 +70,321 @@ func (r *listingRepository) GetListingByID(id string) (*domain.GetListingDetails
 		&listing.Description, &listing.Type, &listing.Price, &listing.Location, &listing.Bathrooms,
 		&listing.Bedrooms, &listing.ImageKeys, &listing.IsAirConditioned, &listing.IsBalconyAvailable,
 		&listing.IsDryerAvailable, &listing.IsHeated, &listing.IsParkingAvailable,
-		&listing.IsPoolAvailable, &listing.IsWasherAvailable, &listing.IsWifiAvailable, &listing.UserID)
+		&listing.IsPoolAvailable, &listing.IsWasherAvailable, &listing.IsWifiAvailable, &listing.UserID,
+		&listing.Latitude, &listing.Longitude, &listing.CreatedAt, &listing.Version, &listing.Status, &listing.PreviousPrice, &listing.PriceDroppedAt,
+		&listing.Amenities, &listing.BookmarkCount, &listing.Rating.Average, &listing.Rating.Count,
+		&listing.OwnerRating.Average, &listing.OwnerRating.Count)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrListingNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
 
-	return &listing, err
+	return &listing, nil
 }
 
-func (r *listingRepository) GetListings() (*domain.GetListingsResponse, error) {
-	query := `
-		SELECT id, title, type, price, location, bathrooms, bedrooms, image_keys
-		FROM listings`
+func (r *listingRepository) GetListings(filter *domain.ListingFilter) (*domain.GetListingsResponse, error) {
+	q := buildListingFilter(filter)
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM listings` + q.where
+	if err := r.pool.QueryRow(context.Background(), countQuery, q.args...).Scan(&total); err != nil {
+		return nil, err
+	}
+
+	where, args := q.where, q.args
+	offset := (filter.Page - 1) * filter.Limit
+	if filter.After != nil {
+		operator := "<"
+		if filter.Order == "asc" {
+			operator = ">"
+		}
+		args = append(args, filter.After.CreatedAt, filter.After.ID)
+		where = andWhere(where, fmt.Sprintf("(created_at, id) %s ($%d, $%d)", operator, len(args)-1, len(args)))
+		offset = 0
+	}
+
+	searchColumns := `0::real, '', ''`
+	if q.tsquery != "" {
+		searchColumns = fmt.Sprintf(`
+		ts_rank(search_vector, %[1]s),
+		ts_headline('english', %[2]s, %[1]s, 'HighlightAll=true, StartSel=<mark>, StopSel=</mark>'),
+		ts_headline('english', %[3]s, %[1]s, 'MaxFragments=2, StartSel=<mark>, StopSel=</mark>')`,
+			q.tsquery, htmlEscaped("title"), htmlEscaped("description"))
+	}
 
-	rows, err := r.pool.Query(context.Background(), query)
+	distanceColumn := `NULL::double precision`
+	if q.distance != "" {
+		distanceColumn = q.distance
+	}
+
+	viewerArg := ""
+	if filter.ViewerID != "" {
+		args = append(args, filter.ViewerID)
+		viewerArg = fmt.Sprintf("$%d", len(args))
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, title, type, price, location, bathrooms, bedrooms, image_keys, created_at,
+		latitude, longitude, status, previous_price, price_dropped_at, %s, %s, %s, %s, %s
+		FROM listings%s
+		ORDER BY %s
+		LIMIT $%d OFFSET $%d`, amenitiesColumn("listings"), isBookmarkedColumn("listings", viewerArg), bookmarkCountColumn("listings"),
+		distanceColumn, searchColumns, where, listingOrderBy(filter, q), len(args)+1, len(args)+2)
+	args = append(args, filter.Limit+1, offset)
+
+	rows, err := r.pool.Query(context.Background(), query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	listings := []domain.ListingInfo{}
+	listings, err := scanListingInfos(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &domain.GetListingsResponse{Listings: listings, Total: total}
+	if len(listings) > filter.Limit {
+		response.Listings = listings[:filter.Limit]
+		if filter.Sort == "created_at" {
+			last := response.Listings[filter.Limit-1]
+			response.NextCursor = pkg.EncodeCursor(last.CreatedAt, last.ID)
+		}
+	}
+
+	return response, nil
+}
+
+// GetListingClusters groups the filtered listings into square grid cells of
+// cellSize degrees. Cells holding a single listing come back as points.
+func (r *listingRepository) GetListingClusters(filter *domain.ListingFilter, cellSize float64) (*domain.GetListingClustersResponse, error) {
+	q := buildListingFilter(filter)
+	where := andWhere(q.where, "latitude IS NOT NULL AND longitude IS NOT NULL")
+	args := append(q.args, cellSize)
+
+	query := fmt.Sprintf(`
+		SELECT COUNT(*), AVG(latitude), AVG(longitude), MIN(price), MAX(price),
+		(ARRAY_AGG(id))[1], (ARRAY_AGG(title))[1]
+		FROM listings%s
+		GROUP BY FLOOR(latitude / $%[2]d), FLOOR(longitude / $%[2]d)`, where, len(args))
+
+	rows, err := r.pool.Query(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	response := &domain.GetListingClustersResponse{
+		Clusters: []domain.ListingCluster{},
+		Points:   []domain.ListingPoint{},
+	}
 	for rows.Next() {
-		var listing domain.ListingInfo
-		err := rows.Scan(&listing.ID, &listing.Title, &listing.Type, &listing.Price, &listing.Location, &listing.Bathrooms, &listing.Bedrooms, &listing.ImageKeys)
+		var cluster domain.ListingCluster
+		var id, title string
+		err := rows.Scan(&cluster.Count, &cluster.Latitude, &cluster.Longitude,
+			&cluster.MinPrice, &cluster.MaxPrice, &id, &title)
 		if err != nil {
 			return nil, err
 		}
-		listings = append(listings, listing)
+
+		if cluster.Count == 1 {
+			response.Points = append(response.Points, domain.ListingPoint{
+				ID:        id,
+				Title:     title,
+				Price:     cluster.MinPrice,
+				Latitude:  cluster.Latitude,
+				Longitude: cluster.Longitude,
+			})
+			continue
+		}
+		response.Clusters = append(response.Clusters, cluster)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
-	return &domain.GetListingsResponse{Listings: listings}, nil
+	return response, nil
 }
 
-func (r *listingRepository) UpdateListing(listing *domain.Listing) error {
+func (r *listingRepository) UpdateListing(listing *domain.Listing, expectedVersion *int) error {
 	query := `
 		UPDATE listings
-		SET title = $1, description = $2, type = $3, price = $4, location = $5, bathrooms = $6, bedrooms = $7, image_keys = $8, is_air_conditioned = $9, is_balcony_available = $10, is_dryer_available = $11, is_heated = $12, is_parking_available = $13, is_pool_available = $14, is_washer_available = $15, is_wifi_available = $16
-		WHERE id = $1
+		SET title = $1, description = $2, type = $3, price = $4, location = $5, bathrooms = $6, bedrooms = $7, image_keys = $8, is_air_conditioned = $9, is_balcony_available = $10, is_dryer_available = $11, is_heated = $12, is_parking_available = $13, is_pool_available = $14, is_washer_available = $15, is_wifi_available = $16, latitude = $17, longitude = $18,
+		version = version + 1
+		WHERE id = $19 AND ($20::int IS NULL OR version = $20)
 	`
 
-	_, err := r.pool.Exec(context.Background(), query, listing.Title, listing.Description, listing.Type, listing.Price, listing.Location, listing.Bathrooms, listing.Bedrooms, listing.ImageKeys, listing.IsAirConditioned, listing.IsBalconyAvailable, listing.IsDryerAvailable, listing.IsHeated, listing.IsParkingAvailable, listing.IsPoolAvailable, listing.IsWasherAvailable, listing.IsWifiAvailable)
-	return err
+	return pgx.BeginFunc(context.Background(), r.pool, func(tx pgx.Tx) error {
+		oldPrice, err := lockListingPrice(context.Background(), tx, listing.ID)
+		if err != nil {
+			return err
+		}
+
+		tag, err := tx.Exec(context.Background(), query, listing.Title, listing.Description, listing.Type, listing.Price, listing.Location, listing.Bathrooms, listing.Bedrooms, listing.ImageKeys, listing.IsAirConditioned, listing.IsBalconyAvailable, listing.IsDryerAvailable, listing.IsHeated, listing.IsParkingAvailable, listing.IsPoolAvailable, listing.IsWasherAvailable, listing.IsWifiAvailable, listing.Latitude, listing.Longitude, listing.ID, expectedVersion)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return domain.ErrVersionConflict
+		}
+
+		if err := recordPriceChange(context.Background(), tx, listing.ID, oldPrice, listing.Price); err != nil {
+			return err
+		}
+
+		return syncListingAmenities(context.Background(), tx, listing.ID, listing.AmenityIDs)
+	})
+}
+
+func (r *listingRepository) PatchListing(id string, req *domain.UpdateListingRequest, expectedVersion *int) error {
+	var assignments []string
+	var args []any
+
+	set := func(column string, value any) {
+		args = append(args, value)
+		assignments = append(assignments, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+
+	if req.Title != nil {
+		set("title", *req.Title)
+	}
+	if req.Description != nil {
+		set("description", *req.Description)
+	}
+	if req.Type != nil {
+		set("type", *req.Type)
+	}
+	if req.Price != nil {
+		set("price", *req.Price)
+	}
+	if req.Location != nil {
+		set("location", *req.Location)
+	}
+	if req.Latitude != nil {
+		set("latitude", *req.Latitude)
+	}
+	if req.Longitude != nil {
+		set("longitude", *req.Longitude)
+	}
+	if req.Bathrooms != nil {
+		set("bathrooms", *req.Bathrooms)
+	}
+	if req.Bedrooms != nil {
+		set("bedrooms", *req.Bedrooms)
+	}
+	if req.ImageKeys != nil {
+		set("image_keys", *req.ImageKeys)
+	}
+
+	amenities := []struct {
+		column string
+		value  *bool
+	}{
+		{"is_air_conditioned", req.IsAirConditioned},
+		{"is_balcony_available", req.IsBalconyAvailable},
+		{"is_dryer_available", req.IsDryerAvailable},
+		{"is_heated", req.IsHeated},
+		{"is_parking_available", req.IsParkingAvailable},
+		{"is_pool_available", req.IsPoolAvailable},
+		{"is_washer_available", req.IsWasherAvailable},
+		{"is_wifi_available", req.IsWifiAvailable},
+	}
+	for _, amenity := range amenities {
+		if amenity.value != nil {
+			set(amenity.column, *amenity.value)
+		}
+	}
+
+	if len(assignments) == 0 && req.AmenityIDs == nil {
+		return domain.ErrNothingToUpdate
+	}
+
+	assignments = append(assignments, "version = version + 1")
+	args = append(args, id)
+	query := fmt.Sprintf(`UPDATE listings SET %s WHERE id = $%d`, strings.Join(assignments, ", "), len(args))
+	if expectedVersion != nil {
+		args = append(args, *expectedVersion)
+		query += fmt.Sprintf(` AND version = $%d`, len(args))
+	}
+
+	return pgx.BeginFunc(context.Background(), r.pool, func(tx pgx.Tx) error {
+		oldPrice, err := lockListingPrice(context.Background(), tx, id)
+		if err != nil {
+			return err
+		}
+
+		tag, err := tx.Exec(context.Background(), query, args...)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return domain.ErrVersionConflict
+		}
+
+		if req.Price != nil {
+			if err := recordPriceChange(context.Background(), tx, id, oldPrice, *req.Price); err != nil {
+				return err
+			}
+		}
+
+		var amenityIDs []string
+		if req.AmenityIDs != nil {
+			amenityIDs = *req.AmenityIDs
+		}
+		return syncListingAmenities(context.Background(), tx, id, amenityIDs)
+	})
+}
+
+func (r *listingRepository) GetPriceHistory(listingID string) ([]domain.PriceChange, error) {
+	query := `
+		SELECT old_price, new_price, changed_at
+		FROM listing_price_history
+		WHERE listing_id = $1
+		ORDER BY changed_at DESC, id DESC
+	`
+
+	rows, err := r.pool.Query(context.Background(), query, listingID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []domain.PriceChange{}
+	for rows.Next() {
+		var change domain.PriceChange
+		if err := rows.Scan(&change.OldPrice, &change.NewPrice, &change.ChangedAt); err != nil {
+			return nil, err
+		}
+		history = append(history, change)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+// UpdateListingStatus stamps published_at when a hidden listing becomes
+// public, but not when it moves between public statuses.
+func (r *listingRepository) UpdateListingStatus(id, status string) error {
+	query := `
+		UPDATE listings SET status = $1, version = version + 1,
+		published_at = CASE WHEN $1::text = ANY($3::text[]) AND NOT (status = ANY($3::text[])) THEN NOW() ELSE published_at END
+		WHERE id = $2
+	`
+
+	tag, err := r.pool.Exec(context.Background(), query, status, id, domain.PublicListingStatuses)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrListingNotFound
+	}
+	return nil
 }
 
 func (r *listingRepository) DeleteListing(id string) error {
@@ -99,49 +397,469 @@ func (r *listingRepository) DeleteListing(id string) error {
 	return err
 }
 
+// BookmarkListing treats a listing the user can't see like a missing one, so
+// the response doesn't reveal hidden listings.
 func (r *listingRepository) BookmarkListing(userID, listingID string) error {
 	query := `
-		INSERT INTO bookmarks (user_id, listing_id)
-		VALUES ($1, $2)
+		WITH listing AS (
+			SELECT l.id FROM listings l
+			WHERE l.id = $2 AND ` + visibleToCondition("l", "$1", "$3") + `
+		), inserted AS (
+			INSERT INTO bookmarks (user_id, listing_id)
+			SELECT $1, id FROM listing
+			ON CONFLICT (user_id, listing_id) WHERE collection_id IS NULL DO NOTHING
+		)
+		SELECT EXISTS (SELECT 1 FROM listing)
 	`
 
-	_, err := r.pool.Exec(context.Background(), query, userID, listingID)
-	return err
+	var found bool
+	err := r.pool.QueryRow(context.Background(), query, userID, listingID, domain.PublicListingStatuses).Scan(&found)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return domain.ErrListingNotFound
+	}
+	return nil
+}
+
+func (r *listingRepository) IsBookmarked(userID, listingID string) (bool, error) {
+	query := `
+		SELECT EXISTS(
+			SELECT 1 FROM bookmarks
+			WHERE user_id = $1 AND listing_id = $2 AND collection_id IS NULL
+		)
+	`
+
+	var bookmarked bool
+	err := r.pool.QueryRow(context.Background(), query, userID, listingID).Scan(&bookmarked)
+	return bookmarked, err
 }
 
 func (r *listingRepository) UnbookmarkListing(userID, listingID string) error {
 	query := `
 		DELETE FROM bookmarks
-		WHERE user_id = $1 AND listing_id = $2
+		WHERE user_id = $1 AND listing_id = $2 AND collection_id IS NULL
 	`
 
 	_, err := r.pool.Exec(context.Background(), query, userID, listingID)
 	return err
 }
 
-func (r *listingRepository) GetBookmarkedListings(userID string) ([]domain.ListingInfo, error) {
+func (r *listingRepository) GetBookmarkedListings(userID string, after *domain.Cursor, limit int) (*domain.GetBookmarkedListingsResponse, error) {
 	query := `
-		SELECT l.id, l.title, l.type, l.price, l.location, l.bathrooms, l.bedrooms, l.image_keys
+		SELECT l.id, l.title, l.type, l.price, l.location, l.bathrooms, l.bedrooms, l.image_keys, l.created_at,
+		l.latitude, l.longitude, l.status, l.previous_price, l.price_dropped_at, ` + amenitiesColumn("l") + `,
+		` + bookmarkCountColumn("l") + `, b.created_at, b.id
 		FROM listings l
 		JOIN bookmarks b ON l.id = b.listing_id
-		WHERE b.user_id = $1
+		WHERE b.user_id = $1 AND b.collection_id IS NULL AND ` + visibleToCondition("l", "$1", "$2") + `
 	`
+	args := []any{userID, domain.PublicListingStatuses}
+	if after != nil {
+		query += ` AND (b.created_at, b.id) < ($3, $4)`
+		args = append(args, after.CreatedAt, after.ID)
+	}
+	query += fmt.Sprintf(` ORDER BY b.created_at DESC, b.id DESC LIMIT $%d`, len(args)+1)
+	args = append(args, limit+1)
 
-	rows, err := r.pool.Query(context.Background(), query, userID)
+	rows, err := r.pool.Query(context.Background(), query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	listings := []domain.ListingInfo{}
+	var cursors []domain.Cursor
+	for rows.Next() {
+		var listing domain.ListingInfo
+		var cursor domain.Cursor
+		err := rows.Scan(&listing.ID, &listing.Title, &listing.Type, &listing.Price, &listing.Location,
+			&listing.Bathrooms, &listing.Bedrooms, &listing.ImageKeys, &listing.CreatedAt,
+			&listing.Latitude, &listing.Longitude, &listing.Status, &listing.PreviousPrice, &listing.PriceDroppedAt,
+			&listing.Amenities, &listing.BookmarkCount, &cursor.CreatedAt, &cursor.ID)
+		if err != nil {
+			return nil, err
+		}
+		listing.IsBookmarked = true
+		listings = append(listings, listing)
+		cursors = append(cursors, cursor)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	response := &domain.GetBookmarkedListingsResponse{Listings: listings}
+	if len(listings) > limit {
+		response.Listings = listings[:limit]
+		last := cursors[limit-1]
+		response.NextCursor = pkg.EncodeCursor(last.CreatedAt, last.ID)
+	}
+
+	return response, nil
+}
+
+func (r *listingRepository) SetBookmarkNotify(userID, listingID string, notify bool) error {
+	query := `UPDATE bookmarks SET notify = $1 WHERE user_id = $2 AND listing_id = $3 AND collection_id IS NULL`
+
+	tag, err := r.pool.Exec(context.Background(), query, notify, userID, listingID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrBookmarkNotFound
+	}
+	return nil
+}
+
+func (r *listingRepository) GetBookmarkSubscribers(listingID string) ([]string, error) {
+	query := `SELECT user_id FROM bookmarks WHERE listing_id = $1 AND collection_id IS NULL AND notify`
+
+	rows, err := r.pool.Query(context.Background(), query, listingID)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
+
+func scanListingInfos(rows pgx.Rows) ([]domain.ListingInfo, error) {
 	listings := []domain.ListingInfo{}
 	for rows.Next() {
 		var listing domain.ListingInfo
-		err := rows.Scan(&listing.ID, &listing.Title, &listing.Type, &listing.Price, &listing.Location, &listing.Bathrooms, &listing.Bedrooms, &listing.ImageKeys)
+		err := rows.Scan(&listing.ID, &listing.Title, &listing.Type, &listing.Price, &listing.Location,
+			&listing.Bathrooms, &listing.Bedrooms, &listing.ImageKeys, &listing.CreatedAt,
+			&listing.Latitude, &listing.Longitude, &listing.Status, &listing.PreviousPrice, &listing.PriceDroppedAt,
+			&listing.Amenities, &listing.IsBookmarked, &listing.BookmarkCount, &listing.DistanceKm,
+			&listing.Rank, &listing.TitleHighlight, &listing.Snippet)
 		if err != nil {
 			return nil, err
 		}
 		listings = append(listings, listing)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
 	return listings, nil
 }
+
+// listingQuery is a compiled ListingFilter. tsquery and distance are SQL
+// expressions that reference args, so they can be reused in the select list
+// and ORDER BY; they are empty when the filter does not use them.
+type listingQuery struct {
+	where    string
+	args     []any
+	tsquery  string
+	distance string
+}
+
+const earthRadiusKm = 6371.0
+
+// buildListingFilter turns the filter into a WHERE clause (with a leading
+// space, or empty) and its positional arguments.
+func buildListingFilter(filter *domain.ListingFilter) *listingQuery {
+	q := &listingQuery{}
+	var conditions []string
+
+	arg := func(value any) string {
+		q.args = append(q.args, value)
+		return fmt.Sprintf("$%d", len(q.args))
+	}
+	add := func(condition string, value any) {
+		conditions = append(conditions, fmt.Sprintf(condition, arg(value)))
+	}
+
+	if filter.Q != "" {
+		q.tsquery = fmt.Sprintf("websearch_to_tsquery('english', %s)", arg(filter.Q))
+		conditions = append(conditions, "search_vector @@ "+q.tsquery)
+	}
+
+	if len(filter.Statuses) > 0 {
+		add("status = ANY(%s)", filter.Statuses)
+	}
+	if filter.OwnerID != "" {
+		add("user_id = %s", filter.OwnerID)
+	}
+	if filter.PublishedAfter != nil {
+		add("published_at > %s", *filter.PublishedAfter)
+	}
+	if filter.PublishedBefore != nil {
+		add("published_at <= %s", *filter.PublishedBefore)
+	}
+	if filter.Type != "" {
+		add("type = %s", filter.Type)
+	}
+	if filter.MinPrice != nil {
+		add("price >= %s", *filter.MinPrice)
+	}
+	if filter.MaxPrice != nil {
+		add("price <= %s", *filter.MaxPrice)
+	}
+	if filter.MinBedrooms != nil {
+		add("bedrooms >= %s", *filter.MinBedrooms)
+	}
+	if filter.MinBathrooms != nil {
+		add("bathrooms >= %s", *filter.MinBathrooms)
+	}
+	if filter.Location != "" {
+		add("location ILIKE '%%' || %s || '%%'", likeEscaper.Replace(filter.Location))
+	}
+
+	amenities := []struct {
+		column string
+		value  *bool
+	}{
+		{"is_air_conditioned", filter.IsAirConditioned},
+		{"is_balcony_available", filter.IsBalconyAvailable},
+		{"is_dryer_available", filter.IsDryerAvailable},
+		{"is_heated", filter.IsHeated},
+		{"is_parking_available", filter.IsParkingAvailable},
+		{"is_pool_available", filter.IsPoolAvailable},
+		{"is_washer_available", filter.IsWasherAvailable},
+		{"is_wifi_available", filter.IsWifiAvailable},
+	}
+	for _, amenity := range amenities {
+		if amenity.value != nil {
+			add(amenity.column+" = %s", *amenity.value)
+		}
+	}
+
+	if len(filter.Amenities) > 0 {
+		add(`NOT EXISTS (
+			SELECT 1 FROM unnest(%s::uuid[]) AS wanted(id)
+			WHERE NOT EXISTS (
+				SELECT 1 FROM listing_amenities la
+				WHERE la.listing_id = listings.id AND la.amenity_id = wanted.id
+			)
+		)`, filter.Amenities)
+	}
+
+	if filter.AvailableFrom != "" && filter.AvailableTo != "" {
+		from, to := arg(filter.AvailableFrom), arg(filter.AvailableTo)
+		conditions = append(conditions, fmt.Sprintf(`type = 'rent'
+			AND NOT EXISTS (
+				SELECT 1 FROM listing_calendar lc
+				WHERE lc.listing_id = listings.id AND lc.is_blocked AND lc.date >= %[1]s::date AND lc.date < %[2]s::date
+			)
+			AND NOT EXISTS (
+				SELECT 1 FROM reservations r
+				WHERE r.listing_id = listings.id AND r.status = 'confirmed'
+				AND daterange(r.check_in, r.check_out) && daterange(%[1]s::date, %[2]s::date)
+			)
+			AND (%[2]s::date - %[1]s::date >= %[3]d OR NOT EXISTS (
+				SELECT 1 FROM listing_rental_settings rs WHERE rs.listing_id = listings.id AND rs.period = 'monthly'
+			))`, from, to, domain.MonthlyRentalNights))
+	}
+
+	if box := filter.Bounds; box != nil {
+		add("latitude >= %s", box.MinLat)
+		add("latitude <= %s", box.MaxLat)
+		if box.MinLng <= box.MaxLng {
+			add("longitude >= %s", box.MinLng)
+			add("longitude <= %s", box.MaxLng)
+		} else {
+			// The box crosses the antimeridian.
+			conditions = append(conditions, fmt.Sprintf("(longitude >= %s OR longitude <= %s)", arg(box.MinLng), arg(box.MaxLng)))
+		}
+	}
+
+	if filter.Lat != nil && filter.Lng != nil {
+		lat, lng := arg(*filter.Lat), arg(*filter.Lng)
+		q.distance = fmt.Sprintf(`(%[3]f * 2 * ASIN(SQRT(
+			POWER(SIN(RADIANS(latitude - %[1]s) / 2), 2) +
+			COS(RADIANS(%[1]s)) * COS(RADIANS(latitude)) * POWER(SIN(RADIANS(longitude - %[2]s) / 2), 2)
+		)))`, lat, lng, earthRadiusKm)
+
+		if filter.RadiusKm != nil {
+			// Cheap latitude band first so the index can be used, then the
+			// exact great-circle distance.
+			delta := *filter.RadiusKm / (math.Pi * earthRadiusKm / 180)
+			add("latitude >= %s", *filter.Lat-delta)
+			add("latitude <= %s", *filter.Lat+delta)
+			add(q.distance+" <= %s", *filter.RadiusKm)
+		}
+	}
+
+	if len(conditions) > 0 {
+		q.where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	return q
+}
+
+func andWhere(where, condition string) string {
+	if where == "" {
+		return " WHERE " + condition
+	}
+	return where + " AND " + condition
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// listingOrderBy only ever returns whitelisted columns or expressions built
+// by buildListingFilter, so its output is safe to interpolate into the query.
+func listingOrderBy(filter *domain.ListingFilter, q *listingQuery) string {
+	column, direction := "created_at", "DESC"
+	switch filter.Sort {
+	case "price":
+		column, direction = "price", "ASC"
+	case "relevance":
+		if q.tsquery != "" {
+			column = "ts_rank(search_vector, " + q.tsquery + ")"
+		}
+	case "distance":
+		if q.distance != "" {
+			column, direction = q.distance, "ASC"
+		}
+	}
+
+	switch filter.Order {
+	case "asc":
+		direction = "ASC"
+	case "desc":
+		direction = "DESC"
+	}
+
+	return fmt.Sprintf("%s %s, id %s", column, direction, direction)
+}
+
+// lockListingPrice reads the current price and locks the row until the
+// transaction ends, so concurrent edits record a consistent price history.
+func lockListingPrice(ctx context.Context, tx pgx.Tx, listingID string) (int, error) {
+	var price int
+	err := tx.QueryRow(ctx, `SELECT price FROM listings WHERE id = $1 FOR UPDATE`, listingID).Scan(&price)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, domain.ErrListingNotFound
+	}
+	return price, err
+}
+
+// recordPriceChange appends to the price history and updates the "reduced"
+// markers on the listing. A price rise clears price_dropped_at.
+func recordPriceChange(ctx context.Context, tx pgx.Tx, listingID string, oldPrice, newPrice int) error {
+	if oldPrice == newPrice {
+		return nil
+	}
+
+	query := `INSERT INTO listing_price_history (listing_id, old_price, new_price) VALUES ($1, $2, $3)`
+	if _, err := tx.Exec(ctx, query, listingID, oldPrice, newPrice); err != nil {
+		return err
+	}
+
+	var droppedAt *time.Time
+	if newPrice < oldPrice {
+		now := time.Now()
+		droppedAt = &now
+	}
+
+	query = `UPDATE listings SET previous_price = $1, price_dropped_at = $2 WHERE id = $3`
+	_, err := tx.Exec(ctx, query, oldPrice, droppedAt, listingID)
+	return err
+}
+
+// legacyAmenityColumns are the boolean columns that predate the amenities
+// catalog. Each has a seeded amenity whose legacy_column names it.
+var legacyAmenityColumns = []string{
+	"is_air_conditioned",
+	"is_balcony_available",
+	"is_dryer_available",
+	"is_heated",
+	"is_parking_available",
+	"is_pool_available",
+	"is_washer_available",
+	"is_wifi_available",
+}
+
+// syncListingAmenities keeps listing_amenities and the legacy boolean columns
+// in step. A non-nil amenityIDs is authoritative and the booleans are derived
+// from it; otherwise the booleans, as just written, are mirrored into the join
+// table and catalog-only amenities are left alone.
+func syncListingAmenities(ctx context.Context, tx pgx.Tx, listingID string, amenityIDs []string) error {
+	if amenityIDs != nil {
+		if _, err := tx.Exec(ctx, `DELETE FROM listing_amenities WHERE listing_id = $1`, listingID); err != nil {
+			return err
+		}
+
+		query := `
+			INSERT INTO listing_amenities (listing_id, amenity_id)
+			SELECT $1, id FROM amenities WHERE id = ANY($2::uuid[])
+		`
+		if _, err := tx.Exec(ctx, query, listingID, amenityIDs); err != nil {
+			return err
+		}
+
+		assignments := make([]string, len(legacyAmenityColumns))
+		for i, column := range legacyAmenityColumns {
+			assignments[i] = fmt.Sprintf(`%[1]s = EXISTS (
+				SELECT 1 FROM listing_amenities la JOIN amenities a ON a.id = la.amenity_id
+				WHERE la.listing_id = listings.id AND a.legacy_column = '%[1]s')`, column)
+		}
+		_, err := tx.Exec(ctx, `UPDATE listings SET `+strings.Join(assignments, ", ")+` WHERE id = $1`, listingID)
+		return err
+	}
+
+	query := `
+		DELETE FROM listing_amenities la USING amenities a
+		WHERE la.amenity_id = a.id AND la.listing_id = $1 AND a.legacy_column IS NOT NULL
+	`
+	if _, err := tx.Exec(ctx, query, listingID); err != nil {
+		return err
+	}
+
+	cases := make([]string, len(legacyAmenityColumns))
+	for i, column := range legacyAmenityColumns {
+		cases[i] = fmt.Sprintf("WHEN '%[1]s' THEN l.%[1]s", column)
+	}
+	query = `
+		INSERT INTO listing_amenities (listing_id, amenity_id)
+		SELECT l.id, a.id FROM listings l
+		JOIN amenities a ON CASE a.legacy_column ` + strings.Join(cases, " ") + ` ELSE FALSE END
+		WHERE l.id = $1
+	`
+	_, err := tx.Exec(ctx, query, listingID)
+	return err
+}
+
+// isBookmarkedColumn reports whether the viewer bookmarked the listing.
+// viewerArg is the placeholder holding the viewer's ID, or empty for
+// anonymous requests.
+func isBookmarkedColumn(table, viewerArg string) string {
+	if viewerArg == "" {
+		return "FALSE"
+	}
+	return fmt.Sprintf(`EXISTS (
+		SELECT 1 FROM bookmarks vb
+		WHERE vb.listing_id = %s.id AND vb.user_id = %s AND vb.collection_id IS NULL)`, table, viewerArg)
+}
+
+// visibleToCondition keeps listings in the statuses held by statusesArg, plus
+// the viewer's own. An anonymous viewer is passed as NULL, which never
+// matches.
+func visibleToCondition(table, viewerArg, statusesArg string) string {
+	return fmt.Sprintf(`(%s.status = ANY(%s) OR %s.user_id = %s)`, table, statusesArg, table, viewerArg)
+}
+
+func bookmarkCountColumn(table string) string {
+	return fmt.Sprintf(`(
+		SELECT COUNT(*) FROM bookmarks cb
+		WHERE cb.listing_id = %s.id AND cb.collection_id IS NULL)`, table)
+}
+
+// amenitiesColumn selects a listing's amenities as a JSON array, which pgx
+// decodes straight into []domain.Amenity.
+func amenitiesColumn(table string) string {
+	return fmt.Sprintf(`COALESCE((
+		SELECT json_agg(json_build_object('id', a.id, 'name', a.name, 'icon', a.icon, 'category', a.category)
+			ORDER BY a.category, a.name)
+		FROM listing_amenities la JOIN amenities a ON a.id = la.amenity_id
+		WHERE la.listing_id = %s.id), '[]')`, table)
+}
+
+// htmlEscaped escapes a text column for HTML, so the <mark> tags added by
+// ts_headline are the only markup in the result.
+func htmlEscaped(column string) string {
+	return fmt.Sprintf(`replace(replace(replace(replace(replace(%s, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;'), '''', '&#39;')`, column)
+}
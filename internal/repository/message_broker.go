@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"message-server/internal/domain"
+	"message-server/pkg"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	brokerChannel = "chat_messages"
+	// NOTIFY payloads must stay under 8000 bytes. Larger messages are stored
+	// in broker_payloads and the notification carries a reference instead.
+	maxNotifyPayload      = 7900
+	brokerPayloadRef      = "ref:"
+	brokerReconnectDelay  = time.Second
+	brokerReceiveDeadline = 5 * time.Second
+)
+
+type memoryBroker struct {
+	mutex    sync.RWMutex
+	handlers map[int]func(*domain.BrokerMessage)
+	nextID   int
+}
+
+// NewMemoryBroker connects the hubs of a single process. It is the default
+// when only one instance runs.
+func NewMemoryBroker() domain.MessageBroker {
+	return &memoryBroker{handlers: make(map[int]func(*domain.BrokerMessage))}
+}
+
+func (b *memoryBroker) Publish(ctx context.Context, message *domain.BrokerMessage) error {
+	b.mutex.RLock()
+	handlers := make([]func(*domain.BrokerMessage), 0, len(b.handlers))
+	for _, handler := range b.handlers {
+		handlers = append(handlers, handler)
+	}
+	b.mutex.RUnlock()
+
+	for _, handler := range handlers {
+		handler(message)
+	}
+	return nil
+}
+
+func (b *memoryBroker) Subscribe(ctx context.Context, handler func(*domain.BrokerMessage)) error {
+	b.mutex.Lock()
+	id := b.nextID
+	b.nextID++
+	b.handlers[id] = handler
+	b.mutex.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		b.mutex.Lock()
+		delete(b.handlers, id)
+		b.mutex.Unlock()
+	}()
+	return nil
+}
+
+type postgresBroker struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresBroker fans messages out to every instance connected to the
+// same database with LISTEN/NOTIFY.
+func NewPostgresBroker(pool *pgxpool.Pool) domain.MessageBroker {
+	return &postgresBroker{pool: pool}
+}
+
+func (b *postgresBroker) Publish(ctx context.Context, message *domain.BrokerMessage) error {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	notification := string(payload)
+	if len(payload) > maxNotifyPayload {
+		id, err := b.storePayload(ctx, notification)
+		if err != nil {
+			return err
+		}
+		notification = brokerPayloadRef + id
+	}
+
+	_, err = b.pool.Exec(ctx, "SELECT pg_notify($1, $2)", brokerChannel, notification)
+	return err
+}
+
+func (b *postgresBroker) storePayload(ctx context.Context, payload string) (string, error) {
+	var id string
+	err := pgx.BeginFunc(ctx, b.pool, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, "DELETE FROM broker_payloads WHERE created_at < NOW() - INTERVAL '1 minute'")
+		if err != nil {
+			return err
+		}
+
+		return tx.QueryRow(ctx, "INSERT INTO broker_payloads (payload) VALUES ($1) RETURNING id", payload).Scan(&id)
+	})
+	return id, err
+}
+
+// Subscribe listens on a connection taken out of the pool, so the LISTEN
+// never leaks to other queries. A dropped connection is replaced; messages
+// published in the meantime are missed, but they are already saved.
+func (b *postgresBroker) Subscribe(ctx context.Context, handler func(*domain.BrokerMessage)) error {
+	conn, err := b.listen(ctx)
+	if err != nil {
+		return err
+	}
+
+	go b.receive(ctx, conn, handler)
+	return nil
+}
+
+func (b *postgresBroker) listen(ctx context.Context) (*pgx.Conn, error) {
+	pooled, err := b.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	conn := pooled.Hijack()
+	if _, err := conn.Exec(ctx, "LISTEN "+brokerChannel); err != nil {
+		conn.Close(context.Background())
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (b *postgresBroker) receive(ctx context.Context, conn *pgx.Conn, handler func(*domain.BrokerMessage)) {
+	defer func() {
+		if conn != nil {
+			conn.Close(context.Background())
+		}
+	}()
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+
+			pkg.Logger.Printf("Broker connection lost, reconnecting: %v", err)
+			conn.Close(context.Background())
+			if conn = b.reconnect(ctx); conn == nil {
+				return
+			}
+			continue
+		}
+
+		message, err := b.decode(ctx, notification.Payload)
+		if err != nil {
+			pkg.Logger.Printf("Failed to decode broker message: %v", err)
+			continue
+		}
+		handler(message)
+	}
+}
+
+// reconnect retries until it is listening again, or returns nil once ctx is
+// cancelled.
+func (b *postgresBroker) reconnect(ctx context.Context) *pgx.Conn {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(brokerReconnectDelay):
+		}
+
+		conn, err := b.listen(ctx)
+		if err == nil {
+			return conn
+		}
+		pkg.Logger.Printf("Failed to reconnect broker: %v", err)
+	}
+}
+
+func (b *postgresBroker) decode(ctx context.Context, payload string) (*domain.BrokerMessage, error) {
+	if id, found := strings.CutPrefix(payload, brokerPayloadRef); found {
+		ctx, cancel := context.WithTimeout(ctx, brokerReceiveDeadline)
+		defer cancel()
+
+		if err := b.pool.QueryRow(ctx, "SELECT payload FROM broker_payloads WHERE id = $1", id).Scan(&payload); err != nil {
+			return nil, err
+		}
+	}
+
+	var message domain.BrokerMessage
+	if err := json.Unmarshal([]byte(payload), &message); err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
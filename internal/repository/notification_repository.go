@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"message-server/internal/domain"
+	"message-server/pkg"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type notificationRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewNotificationRepository(pool *pgxpool.Pool) domain.NotificationRepository {
+	return &notificationRepository{pool: pool}
+}
+
+func (r *notificationRepository) CreateNotifications(userIDs []string, notification *domain.Notification) ([]domain.Notification, error) {
+	if len(userIDs) == 0 {
+		return []domain.Notification{}, nil
+	}
+
+	query := `
+		INSERT INTO notifications (user_id, type, listing_id, title, body)
+		SELECT user_id, $2, $3, $4, $5 FROM unnest($1::uuid[]) AS user_id
+		RETURNING id, user_id, type, listing_id, title, body, read_at, created_at
+	`
+
+	rows, err := r.pool.Query(context.Background(), query, userIDs, notification.Type,
+		notification.ListingID, notification.Title, notification.Body)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	created := []domain.Notification{}
+	for rows.Next() {
+		var n domain.Notification
+		err := rows.Scan(&n.ID, &n.UserID, &n.Type, &n.ListingID, &n.Title, &n.Body, &n.ReadAt, &n.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		created = append(created, n)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+func (r *notificationRepository) GetNotifications(userID string, after *domain.Cursor, limit int) (*domain.GetNotificationsResponse, error) {
+	response := &domain.GetNotificationsResponse{Notifications: []domain.Notification{}}
+
+	countQuery := `SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND read_at IS NULL`
+	if err := r.pool.QueryRow(context.Background(), countQuery, userID).Scan(&response.UnreadCount); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT id, user_id, type, listing_id, title, body, read_at, created_at
+		FROM notifications
+		WHERE user_id = $1
+	`
+	args := []any{userID}
+	if after != nil {
+		query += ` AND (created_at, id) < ($2, $3)`
+		args = append(args, after.CreatedAt, after.ID)
+	}
+	query += fmt.Sprintf(` ORDER BY created_at DESC, id DESC LIMIT $%d`, len(args)+1)
+	args = append(args, limit+1)
+
+	rows, err := r.pool.Query(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var n domain.Notification
+		err := rows.Scan(&n.ID, &n.UserID, &n.Type, &n.ListingID, &n.Title, &n.Body, &n.ReadAt, &n.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		response.Notifications = append(response.Notifications, n)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(response.Notifications) > limit {
+		response.Notifications = response.Notifications[:limit]
+		last := response.Notifications[limit-1]
+		response.NextCursor = pkg.EncodeCursor(last.CreatedAt, last.ID)
+	}
+
+	return response, nil
+}
+
+func (r *notificationRepository) MarkNotificationRead(userID, id string) error {
+	query := `
+		UPDATE notifications SET read_at = COALESCE(read_at, NOW())
+		WHERE id = $1 AND user_id = $2
+	`
+
+	tag, err := r.pool.Exec(context.Background(), query, id, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrNotificationNotFound
+	}
+	return nil
+}
+
+func (r *notificationRepository) MarkAllNotificationsRead(userID string) error {
+	query := `UPDATE notifications SET read_at = NOW() WHERE user_id = $1 AND read_at IS NULL`
+
+	_, err := r.pool.Exec(context.Background(), query, userID)
+	return err
+}
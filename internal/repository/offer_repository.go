@@ -0,0 +1,279 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"message-server/internal/domain"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type offerRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewOfferRepository(pool *pgxpool.Pool) domain.OfferRepository {
+	return &offerRepository{pool: pool}
+}
+
+func (r *offerRepository) CreateOffer(offer *domain.Offer) (string, error) {
+	query := `
+		INSERT INTO offers (listing_id, room_id, customer_id, owner_id, amount, conditions, expires_at, awaiting_user_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $4)
+		RETURNING id
+	`
+
+	var id string
+	err := pgx.BeginFunc(context.Background(), r.pool, func(tx pgx.Tx) error {
+		err := tx.QueryRow(context.Background(), query, offer.ListingID, offer.RoomID, offer.CustomerID,
+			offer.OwnerID, offer.Amount, offer.Conditions, offer.ExpiresAt).Scan(&id)
+		if err != nil {
+			return err
+		}
+
+		return recordOfferEvent(context.Background(), tx, id, offer.CustomerID, domain.OfferEventSubmitted,
+			&offer.Amount, offer.Conditions, &offer.ExpiresAt)
+	})
+	if isUniqueViolation(err) {
+		return "", domain.ErrOfferAlreadyPending
+	}
+	return id, err
+}
+
+const offerSelect = `
+	SELECT o.id, o.listing_id, l.title, o.room_id, o.customer_id, o.owner_id, o.amount, o.conditions,
+	o.expires_at, o.status, o.awaiting_user_id, o.created_at, o.updated_at
+	FROM offers o
+	JOIN listings l ON l.id = o.listing_id
+`
+
+func (r *offerRepository) GetOffer(id string) (*domain.Offer, error) {
+	query := offerSelect + ` WHERE o.id = $1`
+
+	offer, err := scanOffer(r.pool.QueryRow(context.Background(), query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrOfferNotFound
+	}
+	return offer, err
+}
+
+func (r *offerRepository) GetOfferHistory(id string) ([]domain.OfferEvent, error) {
+	query := `
+		SELECT id, actor_id, type, amount, conditions, expires_at, created_at
+		FROM offer_events
+		WHERE offer_id = $1
+		ORDER BY created_at, id
+	`
+
+	rows, err := r.pool.Query(context.Background(), query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []domain.OfferEvent{}
+	for rows.Next() {
+		var event domain.OfferEvent
+		var conditions *string
+		err := rows.Scan(&event.ID, &event.ActorID, &event.Type, &event.Amount, &conditions,
+			&event.ExpiresAt, &event.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if conditions != nil {
+			event.Conditions = *conditions
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+func (r *offerRepository) GetUserOffers(userID string) ([]domain.Offer, error) {
+	query := offerSelect + `
+		WHERE o.customer_id = $1 OR o.owner_id = $1
+		ORDER BY o.updated_at DESC, o.id DESC
+	`
+
+	return r.queryOffers(query, userID)
+}
+
+func (r *offerRepository) CounterOffer(id, actorID, awaitingUserID string, req *domain.OfferRequest, expiresAt time.Time) error {
+	query := `
+		UPDATE offers
+		SET amount = $1, conditions = $2, expires_at = $3, awaiting_user_id = $4, updated_at = NOW()
+		WHERE id = $5 AND status = 'pending' AND awaiting_user_id = $6
+	`
+
+	return pgx.BeginFunc(context.Background(), r.pool, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(context.Background(), query, req.Amount, req.Conditions, expiresAt, awaitingUserID, id, actorID)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return domain.ErrOfferClosed
+		}
+
+		return recordOfferEvent(context.Background(), tx, id, actorID, domain.OfferEventCountered,
+			&req.Amount, req.Conditions, &expiresAt)
+	})
+}
+
+func (r *offerRepository) CloseOffer(id, actorID, status, eventType string) error {
+	query := `UPDATE offers SET status = $1, updated_at = NOW() WHERE id = $2 AND status = 'pending'`
+
+	return pgx.BeginFunc(context.Background(), r.pool, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(context.Background(), query, status, id)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return domain.ErrOfferClosed
+		}
+
+		return recordOfferEvent(context.Background(), tx, id, actorID, eventType, nil, "", nil)
+	})
+}
+
+// AcceptOffer locks the listing row first, so concurrent accepts on the same
+// listing are serialised and only the first one finds it published. The
+// other offers are rejected on the owner's behalf.
+func (r *offerRepository) AcceptOffer(id, actorID string) ([]string, error) {
+	ctx := context.Background()
+
+	var rejected []string
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		var listingID, ownerID, status string
+		err := tx.QueryRow(ctx, `
+			SELECT l.id, l.user_id, l.status
+			FROM offers o
+			JOIN listings l ON l.id = o.listing_id
+			WHERE o.id = $1
+			FOR UPDATE OF l
+		`, id).Scan(&listingID, &ownerID, &status)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ErrOfferNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if status != domain.ListingStatusPublished {
+			return domain.ErrListingNotOfferable
+		}
+
+		tag, err := tx.Exec(ctx, `
+			UPDATE offers SET status = 'accepted', updated_at = NOW()
+			WHERE id = $1 AND status = 'pending' AND awaiting_user_id = $2
+		`, id, actorID)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return domain.ErrOfferClosed
+		}
+		if err := recordOfferEvent(ctx, tx, id, actorID, domain.OfferEventAccepted, nil, "", nil); err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, `UPDATE listings SET status = $1, version = version + 1 WHERE id = $2`,
+			domain.ListingStatusUnderOffer, listingID)
+		if err != nil {
+			return err
+		}
+
+		rows, err := tx.Query(ctx, `
+			UPDATE offers SET status = 'rejected', updated_at = NOW()
+			WHERE listing_id = $1 AND status = 'pending'
+			RETURNING id
+		`, listingID)
+		if err != nil {
+			return err
+		}
+		rejected, err = pgx.CollectRows(rows, pgx.RowTo[string])
+		if err != nil {
+			return err
+		}
+
+		for _, rejectedID := range rejected {
+			if err := recordOfferEvent(ctx, tx, rejectedID, ownerID, domain.OfferEventRejected, nil, "", nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rejected, nil
+}
+
+// ExpireOffers records the expiry as done by the party who let it lapse.
+func (r *offerRepository) ExpireOffers(now time.Time) ([]domain.Offer, error) {
+	query := `
+		WITH expired AS (
+			UPDATE offers SET status = 'expired', updated_at = NOW()
+			WHERE status = 'pending' AND expires_at <= $1
+			RETURNING *
+		), events AS (
+			INSERT INTO offer_events (offer_id, actor_id, type)
+			SELECT id, awaiting_user_id, 'offer_expired' FROM expired
+		)
+		SELECT o.id, o.listing_id, l.title, o.room_id, o.customer_id, o.owner_id, o.amount, o.conditions,
+		o.expires_at, o.status, o.awaiting_user_id, o.created_at, o.updated_at
+		FROM expired o
+		JOIN listings l ON l.id = o.listing_id
+	`
+
+	return r.queryOffers(query, now)
+}
+
+func (r *offerRepository) queryOffers(query string, args ...any) ([]domain.Offer, error) {
+	rows, err := r.pool.Query(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	offers := []domain.Offer{}
+	for rows.Next() {
+		offer, err := scanOffer(rows)
+		if err != nil {
+			return nil, err
+		}
+		offers = append(offers, *offer)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return offers, nil
+}
+
+func recordOfferEvent(ctx context.Context, tx pgx.Tx, offerID, actorID, eventType string, amount *int, conditions string, expiresAt *time.Time) error {
+	query := `
+		INSERT INTO offer_events (offer_id, actor_id, type, amount, conditions, expires_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6)
+	`
+
+	_, err := tx.Exec(ctx, query, offerID, actorID, eventType, amount, conditions, expiresAt)
+	return err
+}
+
+func scanOffer(row pgx.Row) (*domain.Offer, error) {
+	var offer domain.Offer
+	err := row.Scan(&offer.ID, &offer.ListingID, &offer.ListingTitle, &offer.RoomID, &offer.CustomerID,
+		&offer.OwnerID, &offer.Amount, &offer.Conditions, &offer.ExpiresAt, &offer.Status,
+		&offer.AwaitingUserID, &offer.CreatedAt, &offer.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &offer, nil
+}
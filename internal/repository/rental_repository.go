@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"message-server/internal/domain"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type rentalRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewRentalRepository(pool *pgxpool.Pool) domain.RentalRepository {
+	return &rentalRepository{pool: pool}
+}
+
+func (r *rentalRepository) GetRentalPeriod(listingID string) (string, error) {
+	query := `SELECT period FROM listing_rental_settings WHERE listing_id = $1`
+
+	var period string
+	err := r.pool.QueryRow(context.Background(), query, listingID).Scan(&period)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.RentalPeriodNightly, nil
+	}
+	return period, err
+}
+
+func (r *rentalRepository) SetRentalPeriod(listingID, period string) error {
+	query := `
+		INSERT INTO listing_rental_settings (listing_id, period)
+		VALUES ($1, $2)
+		ON CONFLICT (listing_id) DO UPDATE SET period = EXCLUDED.period
+	`
+
+	_, err := r.pool.Exec(context.Background(), query, listingID, period)
+	return err
+}
+
+func (r *rentalRepository) GetCalendarDates(listingID string, from, to time.Time) ([]domain.CalendarDate, error) {
+	query := `
+		SELECT date, is_blocked, price
+		FROM listing_calendar
+		WHERE listing_id = $1 AND date >= $2 AND date < $3
+		ORDER BY date
+	`
+
+	rows, err := r.pool.Query(context.Background(), query, listingID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	dates := []domain.CalendarDate{}
+	for rows.Next() {
+		var date domain.CalendarDate
+		if err := rows.Scan(&date.Date, &date.IsBlocked, &date.Price); err != nil {
+			return nil, err
+		}
+		dates = append(dates, date)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return dates, nil
+}
+
+// SetCalendarDates overwrites the nights in [from, to). Nights set back to
+// the defaults are removed rather than stored.
+func (r *rentalRepository) SetCalendarDates(listingID string, from, to time.Time, isBlocked bool, price *int) error {
+	if !isBlocked && price == nil {
+		query := `DELETE FROM listing_calendar WHERE listing_id = $1 AND date >= $2 AND date < $3`
+		_, err := r.pool.Exec(context.Background(), query, listingID, from, to)
+		return err
+	}
+
+	query := `
+		INSERT INTO listing_calendar (listing_id, date, is_blocked, price)
+		SELECT $1, d::date, $4, $5
+		FROM generate_series($2::date, $3::date - 1, INTERVAL '1 day') AS d
+		ON CONFLICT (listing_id, date) DO UPDATE SET is_blocked = EXCLUDED.is_blocked, price = EXCLUDED.price
+	`
+
+	_, err := r.pool.Exec(context.Background(), query, listingID, from, to, isBlocked, price)
+	return err
+}
+
+func (r *rentalRepository) CreateReservation(reservation *domain.Reservation) (string, error) {
+	query := `
+		INSERT INTO reservations (listing_id, customer_id, owner_id, check_in, check_out, total_price)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+
+	var id string
+	err := r.pool.QueryRow(context.Background(), query, reservation.ListingID, reservation.CustomerID,
+		reservation.OwnerID, reservation.CheckIn, reservation.CheckOut, reservation.TotalPrice).Scan(&id)
+	return id, err
+}
+
+const reservationSelect = `
+	SELECT r.id, r.listing_id, l.title, r.customer_id, u.full_name, r.owner_id,
+	to_char(r.check_in, 'YYYY-MM-DD'), to_char(r.check_out, 'YYYY-MM-DD'), r.check_out - r.check_in,
+	r.total_price, r.status, r.created_at
+	FROM reservations r
+	JOIN listings l ON l.id = r.listing_id
+	JOIN users u ON u.id = r.customer_id
+`
+
+func (r *rentalRepository) GetReservation(id string) (*domain.Reservation, error) {
+	query := reservationSelect + ` WHERE r.id = $1`
+
+	reservation, err := scanReservation(r.pool.QueryRow(context.Background(), query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrReservationNotFound
+	}
+	return reservation, err
+}
+
+func (r *rentalRepository) GetConfirmedReservations(listingID string, from, to time.Time) ([]domain.Reservation, error) {
+	query := reservationSelect + `
+		WHERE r.listing_id = $1 AND r.status = 'confirmed'
+		AND daterange(r.check_in, r.check_out) && daterange($2::date, $3::date)
+		ORDER BY r.check_in
+	`
+
+	return r.queryReservations(query, listingID, from, to)
+}
+
+func (r *rentalRepository) GetUserReservations(userID string) ([]domain.Reservation, error) {
+	query := reservationSelect + `
+		WHERE r.customer_id = $1 OR r.owner_id = $1
+		ORDER BY r.check_in DESC, r.id DESC
+	`
+
+	return r.queryReservations(query, userID)
+}
+
+func (r *rentalRepository) UpdateReservationStatus(id, status string, currentStatuses []string) error {
+	query := `
+		UPDATE reservations SET status = $1, updated_at = NOW()
+		WHERE id = $2 AND status = ANY($3)
+	`
+
+	tag, err := r.pool.Exec(context.Background(), query, status, id, currentStatuses)
+	if isExclusionViolation(err) {
+		return domain.ErrDatesUnavailable
+	}
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrReservationClosed
+	}
+	return nil
+}
+
+func (r *rentalRepository) queryReservations(query string, args ...any) ([]domain.Reservation, error) {
+	rows, err := r.pool.Query(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reservations := []domain.Reservation{}
+	for rows.Next() {
+		reservation, err := scanReservation(rows)
+		if err != nil {
+			return nil, err
+		}
+		reservations = append(reservations, *reservation)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return reservations, nil
+}
+
+func scanReservation(row pgx.Row) (*domain.Reservation, error) {
+	var reservation domain.Reservation
+	err := row.Scan(&reservation.ID, &reservation.ListingID, &reservation.ListingTitle, &reservation.CustomerID,
+		&reservation.CustomerName, &reservation.OwnerID, &reservation.CheckIn, &reservation.CheckOut,
+		&reservation.Nights, &reservation.TotalPrice, &reservation.Status, &reservation.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
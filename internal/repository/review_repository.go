@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"message-server/internal/domain"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type reviewRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewReviewRepository(pool *pgxpool.Pool) domain.ReviewRepository {
+	return &reviewRepository{pool: pool}
+}
+
+func (r *reviewRepository) CreateReview(review *domain.Review, roomID string) (string, error) {
+	query := `
+		INSERT INTO reviews (room_id, listing_id, owner_id, author_id, rating, text)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+
+	var id string
+	err := r.pool.QueryRow(context.Background(), query, roomID, review.ListingID, review.OwnerID,
+		review.AuthorID, review.Rating, review.Text).Scan(&id)
+	if isUniqueViolation(err) {
+		return "", domain.ErrAlreadyReviewed
+	}
+	return id, err
+}
+
+const reviewSelect = `
+	SELECT r.id, COALESCE(r.listing_id::text, ''), COALESCE(l.title, ''), r.owner_id, r.author_id, u.full_name,
+	r.rating, r.text, r.reply, r.replied_at, r.created_at
+	FROM reviews r
+	LEFT JOIN listings l ON l.id = r.listing_id
+	JOIN users u ON u.id = r.author_id
+`
+
+func (r *reviewRepository) GetReview(id string) (*domain.Review, error) {
+	query := reviewSelect + ` WHERE r.id = $1`
+
+	review, err := scanReview(r.pool.QueryRow(context.Background(), query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrReviewNotFound
+	}
+	return review, err
+}
+
+func (r *reviewRepository) ReplyToReview(id, text string) error {
+	query := `UPDATE reviews SET reply = $1, replied_at = NOW() WHERE id = $2 AND reply IS NULL`
+
+	tag, err := r.pool.Exec(context.Background(), query, text, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrAlreadyReplied
+	}
+	return nil
+}
+
+func (r *reviewRepository) GetListingReviews(listingID string) ([]domain.Review, error) {
+	query := reviewSelect + ` WHERE r.listing_id = $1 ORDER BY r.created_at DESC, r.id DESC`
+
+	return r.queryReviews(query, listingID)
+}
+
+func (r *reviewRepository) GetOwnerReviews(ownerID string) ([]domain.Review, error) {
+	query := reviewSelect + ` WHERE r.owner_id = $1 ORDER BY r.created_at DESC, r.id DESC`
+
+	return r.queryReviews(query, ownerID)
+}
+
+func (r *reviewRepository) GetOwnerRating(ownerID string) (*domain.RatingSummary, error) {
+	query := `SELECT ` + ratingColumns("owner_id", "$1")
+
+	var rating domain.RatingSummary
+	err := r.pool.QueryRow(context.Background(), query, ownerID).Scan(&rating.Average, &rating.Count)
+	if err != nil {
+		return nil, err
+	}
+	return &rating, nil
+}
+
+// IsRoomReviewable treats a booked viewing whose slot has ended as
+// completed, the same way viewings are reported. An old room only counts
+// once both participants have written in it.
+func (r *reviewRepository) IsRoomReviewable(roomID string, openedBefore, now time.Time) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM rooms ro
+			WHERE ro.id = $1 AND (
+				(
+					ro.created_at <= $2
+					AND EXISTS (
+						SELECT 1 FROM messages m
+						WHERE m.room_id = ro.id::text AND m.sender_id::text = ro.customer_id AND m.type = 'text'
+					)
+					AND EXISTS (
+						SELECT 1 FROM messages m
+						WHERE m.room_id = ro.id::text AND m.sender_id::text = ro.owner_id AND m.type = 'text'
+					)
+				)
+				OR EXISTS (
+					SELECT 1 FROM viewings v
+					JOIN viewing_slots s ON s.id = v.slot_id
+					WHERE v.room_id = ro.id AND v.status = 'booked' AND s.ends_at <= $3
+				)
+			)
+		)
+	`
+
+	var reviewable bool
+	err := r.pool.QueryRow(context.Background(), query, roomID, openedBefore, now).Scan(&reviewable)
+	return reviewable, err
+}
+
+func (r *reviewRepository) queryReviews(query string, args ...any) ([]domain.Review, error) {
+	rows, err := r.pool.Query(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reviews := []domain.Review{}
+	for rows.Next() {
+		review, err := scanReview(rows)
+		if err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, *review)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return reviews, nil
+}
+
+// ratingColumns selects the average rating, rounded to one decimal, and the
+// number of reviews whose column matches value.
+func ratingColumns(column, value string) string {
+	return fmt.Sprintf(`
+		(SELECT COALESCE(ROUND(AVG(rv.rating), 1), 0)::float8 FROM reviews rv WHERE rv.%[1]s = %[2]s),
+		(SELECT COUNT(*) FROM reviews rv WHERE rv.%[1]s = %[2]s)`, column, value)
+}
+
+func scanReview(row pgx.Row) (*domain.Review, error) {
+	var review domain.Review
+	err := row.Scan(&review.ID, &review.ListingID, &review.ListingTitle, &review.OwnerID, &review.AuthorID,
+		&review.AuthorName, &review.Rating, &review.Text, &review.Reply, &review.RepliedAt, &review.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
@@ -2,9 +2,14 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"message-server/internal/domain"
+	"message-server/pkg"
+	"slices"
+	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -76,14 +81,94 @@ func (db *roomRepository) GetRooms(customerID string) ([]domain.Room, error) {
 	return rooms, nil
 }
 
-func (db *roomRepository) SaveMessage(text, senderID, senderName, roomID string) error {
-	query := "INSERT INTO messages (message, sender_id, sender_name, room_id) VALUES ($1, $2, $3, $4)"
-	_, err := db.pool.Exec(context.Background(), query, text, senderID, senderName, roomID)
+func (db *roomRepository) GetRoomByListingAndCustomer(propertyID, customerID string) (*domain.Room, error) {
+	query := `
+		SELECT id, property_id, owner_id, owner_name, customer_id, customer_name, listing_title, listing_image
+		FROM rooms
+		WHERE property_id = $1 AND customer_id = $2
+	`
+	var room domain.Room
+	err := db.pool.QueryRow(context.Background(), query, propertyID, customerID).Scan(&room.RoomID, &room.PropertyID,
+		&room.OwnerID, &room.OwnerName, &room.CustomerID, &room.CustomerName, &room.Title, &room.Image)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrRoomNotFound
+	}
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return &room, nil
+}
+
+func (db *roomRepository) GetRoom(roomID string) (*domain.Room, error) {
+	query := `
+		SELECT id, property_id, owner_id, owner_name, customer_id, customer_name, listing_title, listing_image
+		FROM rooms
+		WHERE id = $1
+	`
+	var room domain.Room
+	err := db.pool.QueryRow(context.Background(), query, roomID).Scan(&room.RoomID, &room.PropertyID,
+		&room.OwnerID, &room.OwnerName, &room.CustomerID, &room.CustomerName, &room.Title, &room.Image)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrRoomNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &room, nil
+}
+
+// MarkMessagesRead marks the other participant's unread messages up to and
+// including messageID, in the order GetMessagesForRoom returns them.
+func (db *roomRepository) MarkMessagesRead(roomID, readerID, messageID string) (*time.Time, error) {
+	var readAt *time.Time
+	err := pgx.BeginFunc(context.Background(), db.pool, func(tx pgx.Tx) error {
+		var upTo domain.Cursor
+		err := tx.QueryRow(context.Background(),
+			"SELECT created_at, id FROM messages WHERE id = $1 AND room_id = $2",
+			messageID, roomID).Scan(&upTo.CreatedAt, &upTo.ID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ErrMessageNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		query := `
+			UPDATE messages SET read_at = NOW()
+			WHERE room_id = $1 AND sender_id <> $2 AND read_at IS NULL AND (created_at, id) <= ($3, $4)
+			RETURNING read_at
+		`
+		rows, err := tx.Query(context.Background(), query, roomID, readerID, upTo.CreatedAt, upTo.ID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			if err := rows.Scan(&readAt); err != nil {
+				return err
+			}
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return readAt, nil
+}
+
+func (db *roomRepository) SaveMessage(messageType, text, senderID, senderName, roomID string, offerID *string) (string, error) {
+	query := "INSERT INTO messages (type, message, sender_id, sender_name, room_id, offer_id) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id"
+	var id string
+	err := db.pool.QueryRow(context.Background(), query, messageType, text, senderID, senderName, roomID, offerID).Scan(&id)
+	if err != nil {
+		return "", err
+	}
+
+	return id, nil
 }
 
 func (db *roomRepository) CheckUserInRoom(userID, roomID string) (bool, error) {
@@ -97,42 +182,72 @@ func (db *roomRepository) CheckUserInRoom(userID, roomID string) (bool, error) {
 	return exists, nil
 }
 
-func (db *roomRepository) GetMessagesForRoom(roomID string) ([]map[string]any, error) {
+// GetMessagesForRoom pages backwards from the newest message. Each page is
+// returned oldest first so it can be prepended to the conversation as is.
+func (db *roomRepository) GetMessagesForRoom(roomID string, before *domain.Cursor, limit int) (*domain.GetMessagesResponse, error) {
 	query := `
-		SELECT id, message, sender_id, sender_name, room_id, created_at 
+		SELECT id, type, message, sender_id, sender_name, room_id, offer_id, created_at, read_at
 		FROM messages 
 		WHERE room_id = $1 
-		ORDER BY created_at ASC
 	`
+	args := []any{roomID}
+	if before != nil {
+		query += ` AND (created_at, id) < ($2, $3)`
+		args = append(args, before.CreatedAt, before.ID)
+	}
+	query += fmt.Sprintf(` ORDER BY created_at DESC, id DESC LIMIT $%d`, len(args)+1)
+	args = append(args, limit+1)
 
-	rows, err := db.pool.Query(context.Background(), query, roomID)
+	rows, err := db.pool.Query(context.Background(), query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("error retrieving messages: %w", err)
 	}
 	defer rows.Close()
 
 	messages := []map[string]any{}
+	var oldest domain.Cursor
+	hasMore := false
 	for rows.Next() {
-		var id, message, senderID, senderName, roomID string
-		var createdAt any
+		var id, messageType, message, senderID, senderName, roomID string
+		var offerID *string
+		var createdAt time.Time
+		var readAt *time.Time
 
-		if err := rows.Scan(&id, &message, &senderID, &senderName, &roomID, &createdAt); err != nil {
+		if err := rows.Scan(&id, &messageType, &message, &senderID, &senderName, &roomID, &offerID, &createdAt, &readAt); err != nil {
 			return nil, fmt.Errorf("error scanning message row: %w", err)
 		}
 
-		messages = append(messages, map[string]any{
+		if len(messages) == limit {
+			hasMore = true
+			break
+		}
+
+		msg := map[string]any{
 			"id":          id,
+			"type":        messageType,
 			"message":     message,
 			"sender_id":   senderID,
 			"sender_name": senderName,
 			"room_id":     roomID,
 			"created_at":  createdAt,
-		})
+			"read_at":     readAt,
+		}
+		if offerID != nil {
+			msg["offer_id"] = *offerID
+		}
+		messages = append(messages, msg)
+		oldest = domain.Cursor{CreatedAt: createdAt, ID: id}
 	}
 
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("error iterating message rows: %w", err)
 	}
 
-	return messages, nil
+	response := &domain.GetMessagesResponse{Messages: messages}
+	if hasMore {
+		response.NextCursor = pkg.EncodeCursor(oldest.CreatedAt, oldest.ID)
+	}
+
+	slices.Reverse(response.Messages)
+	return response, nil
 }
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"message-server/internal/domain"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type savedSearchRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewSavedSearchRepository(pool *pgxpool.Pool) domain.SavedSearchRepository {
+	return &savedSearchRepository{pool: pool}
+}
+
+const savedSearchColumns = `id, user_id, name, filter, frequency, last_checked_at, created_at`
+
+func (r *savedSearchRepository) CreateSavedSearch(userID string, req *domain.SavedSearchRequest) (*domain.SavedSearch, error) {
+	query := `
+		INSERT INTO saved_searches (user_id, name, filter, frequency)
+		VALUES ($1, $2, $3, $4)
+		RETURNING ` + savedSearchColumns
+
+	row := r.pool.QueryRow(context.Background(), query, userID, req.Name, req.Filter, req.Frequency)
+	return scanSavedSearch(row)
+}
+
+func (r *savedSearchRepository) GetSavedSearches(userID string) ([]domain.SavedSearch, error) {
+	query := `SELECT ` + savedSearchColumns + ` FROM saved_searches WHERE user_id = $1 ORDER BY created_at DESC`
+
+	rows, err := r.pool.Query(context.Background(), query, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return collectSavedSearches(rows)
+}
+
+func (r *savedSearchRepository) GetSavedSearch(userID, id string) (*domain.SavedSearch, error) {
+	query := `SELECT ` + savedSearchColumns + ` FROM saved_searches WHERE id = $1 AND user_id = $2`
+
+	return scanSavedSearch(r.pool.QueryRow(context.Background(), query, id, userID))
+}
+
+func (r *savedSearchRepository) UpdateSavedSearch(userID, id string, req *domain.SavedSearchRequest) (*domain.SavedSearch, error) {
+	query := `
+		UPDATE saved_searches SET name = $1, filter = $2, frequency = $3
+		WHERE id = $4 AND user_id = $5
+		RETURNING ` + savedSearchColumns
+
+	row := r.pool.QueryRow(context.Background(), query, req.Name, req.Filter, req.Frequency, id, userID)
+	return scanSavedSearch(row)
+}
+
+func (r *savedSearchRepository) DeleteSavedSearch(userID, id string) error {
+	query := `DELETE FROM saved_searches WHERE id = $1 AND user_id = $2`
+
+	tag, err := r.pool.Exec(context.Background(), query, id, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrSavedSearchNotFound
+	}
+	return nil
+}
+
+// GetDueSavedSearches reads the clock as LOCALTIMESTAMP, which is what NOW()
+// becomes when stored in the TIMESTAMP columns published_at and
+// last_checked_at.
+func (r *savedSearchRepository) GetDueSavedSearches() ([]domain.SavedSearch, time.Time, error) {
+	query := `
+		SELECT ` + savedSearchColumns + `
+		FROM saved_searches
+		WHERE frequency = 'instant'
+		OR (frequency = 'daily' AND last_checked_at <= $1::timestamp - INTERVAL '1 day')
+	`
+
+	var now time.Time
+	if err := r.pool.QueryRow(context.Background(), `SELECT LOCALTIMESTAMP`).Scan(&now); err != nil {
+		return nil, time.Time{}, err
+	}
+
+	rows, err := r.pool.Query(context.Background(), query, now)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	searches, err := collectSavedSearches(rows)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return searches, now, nil
+}
+
+func (r *savedSearchRepository) MarkSavedSearchChecked(id string, checkedAt time.Time) error {
+	query := `UPDATE saved_searches SET last_checked_at = $1 WHERE id = $2`
+
+	_, err := r.pool.Exec(context.Background(), query, checkedAt, id)
+	return err
+}
+
+func scanSavedSearch(row pgx.Row) (*domain.SavedSearch, error) {
+	var search domain.SavedSearch
+	err := row.Scan(&search.ID, &search.UserID, &search.Name, &search.Filter, &search.Frequency,
+		&search.LastCheckedAt, &search.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrSavedSearchNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &search, nil
+}
+
+func collectSavedSearches(rows pgx.Rows) ([]domain.SavedSearch, error) {
+	defer rows.Close()
+
+	searches := []domain.SavedSearch{}
+	for rows.Next() {
+		search, err := scanSavedSearch(rows)
+		if err != nil {
+			return nil, err
+		}
+		searches = append(searches, *search)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return searches, nil
+}
@@ -2,8 +2,10 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"message-server/internal/domain"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -35,3 +37,40 @@ func (r *userRepository) UpdateUser(name, avatarURL string, userID string) error
 	}
 	return nil
 }
+
+// GetPublicProfile counts a conversation as started by the customer once they
+// have sent a text message, and as answered once the user has sent one.
+func (r *userRepository) GetPublicProfile(username string) (*domain.PublicProfile, error) {
+	query := `
+		SELECT u.id, u.username, u.full_name, COALESCE(u.avatar_key, ''), u.created_at,
+		COUNT(ro.id) FILTER (WHERE EXISTS (
+			SELECT 1 FROM messages m
+			WHERE m.room_id = ro.id::text AND m.sender_id = u.id AND m.type = 'text'
+		)),
+		COUNT(ro.id)
+		FROM users u
+		LEFT JOIN rooms ro ON ro.owner_id = u.id::text AND EXISTS (
+			SELECT 1 FROM messages m
+			WHERE m.room_id = ro.id::text AND m.sender_id::text = ro.customer_id AND m.type = 'text'
+		)
+		WHERE u.username = $1
+		GROUP BY u.id
+	`
+
+	var profile domain.PublicProfile
+	var answered, conversations int
+	err := r.pool.QueryRow(context.Background(), query, username).Scan(&profile.ID, &profile.Username,
+		&profile.FullName, &profile.AvatarKey, &profile.MemberSince, &answered, &conversations)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if conversations > 0 {
+		rate := (answered*100 + conversations/2) / conversations
+		profile.ResponseRate = &rate
+	}
+	return &profile, nil
+}
@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"message-server/internal/domain"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type viewingRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewViewingRepository(pool *pgxpool.Pool) domain.ViewingRepository {
+	return &viewingRepository{pool: pool}
+}
+
+const slotColumns = `s.id, s.listing_id, s.starts_at, s.ends_at,
+	EXISTS (SELECT 1 FROM viewings v WHERE v.slot_id = s.id AND v.status = 'booked')`
+
+func (r *viewingRepository) CreateSlot(listingID string, req *domain.ViewingSlotRequest) (*domain.ViewingSlot, error) {
+	query := `
+		INSERT INTO viewing_slots (listing_id, starts_at, ends_at)
+		VALUES ($1, $2, $3)
+		RETURNING id, listing_id, starts_at, ends_at, FALSE
+	`
+
+	slot, err := scanSlot(r.pool.QueryRow(context.Background(), query, listingID, req.StartsAt, req.EndsAt))
+	if isUniqueViolation(err) {
+		return nil, domain.ErrDuplicateSlot
+	}
+	return slot, err
+}
+
+func (r *viewingRepository) GetSlots(listingID string, from time.Time) ([]domain.ViewingSlot, error) {
+	query := `
+		SELECT ` + slotColumns + `
+		FROM viewing_slots s
+		WHERE s.listing_id = $1 AND s.starts_at > $2
+		ORDER BY s.starts_at
+	`
+
+	rows, err := r.pool.Query(context.Background(), query, listingID, from)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	slots := []domain.ViewingSlot{}
+	for rows.Next() {
+		slot, err := scanSlot(rows)
+		if err != nil {
+			return nil, err
+		}
+		slots = append(slots, *slot)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return slots, nil
+}
+
+func (r *viewingRepository) GetSlot(id string) (*domain.ViewingSlot, error) {
+	query := `SELECT ` + slotColumns + ` FROM viewing_slots s WHERE s.id = $1`
+
+	return scanSlot(r.pool.QueryRow(context.Background(), query, id))
+}
+
+func (r *viewingRepository) DeleteSlot(id string) error {
+	query := `DELETE FROM viewing_slots WHERE id = $1`
+
+	tag, err := r.pool.Exec(context.Background(), query, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrSlotNotFound
+	}
+	return nil
+}
+
+func (r *viewingRepository) CreateViewing(slotID, listingID, customerID, ownerID, roomID string) (string, error) {
+	query := `
+		INSERT INTO viewings (slot_id, listing_id, customer_id, owner_id, room_id)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`
+
+	var id string
+	err := r.pool.QueryRow(context.Background(), query, slotID, listingID, customerID, ownerID, roomID).Scan(&id)
+	if isUniqueViolation(err) {
+		return "", domain.ErrSlotAlreadyBooked
+	}
+	return id, err
+}
+
+const viewingSelect = `
+	SELECT v.id, v.slot_id, v.listing_id, l.title, l.location, v.customer_id, cu.full_name,
+	v.owner_id, ou.full_name, v.room_id, s.starts_at, s.ends_at,
+	CASE WHEN v.status = 'booked' AND s.ends_at < NOW() THEN 'completed' ELSE v.status END,
+	v.created_at
+	FROM viewings v
+	JOIN viewing_slots s ON s.id = v.slot_id
+	JOIN listings l ON l.id = v.listing_id
+	JOIN users cu ON cu.id = v.customer_id
+	JOIN users ou ON ou.id = v.owner_id
+`
+
+func (r *viewingRepository) GetViewing(id string) (*domain.Viewing, error) {
+	query := viewingSelect + ` WHERE v.id = $1`
+
+	viewing, err := scanViewing(r.pool.QueryRow(context.Background(), query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrViewingNotFound
+	}
+	return viewing, err
+}
+
+func (r *viewingRepository) RescheduleViewing(id, slotID string) error {
+	query := `UPDATE viewings SET slot_id = $1, updated_at = NOW() WHERE id = $2 AND status = 'booked'`
+
+	tag, err := r.pool.Exec(context.Background(), query, slotID, id)
+	if isUniqueViolation(err) {
+		return domain.ErrSlotAlreadyBooked
+	}
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrViewingNotActive
+	}
+	return nil
+}
+
+func (r *viewingRepository) CancelViewing(id string) error {
+	query := `UPDATE viewings SET status = 'cancelled', updated_at = NOW() WHERE id = $1 AND status = 'booked'`
+
+	tag, err := r.pool.Exec(context.Background(), query, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrViewingNotActive
+	}
+	return nil
+}
+
+func (r *viewingRepository) GetUpcomingViewings(userID string, from time.Time) ([]domain.Viewing, error) {
+	query := viewingSelect + `
+		WHERE (v.customer_id = $1 OR v.owner_id = $1) AND v.status = 'booked' AND s.ends_at > $2
+		ORDER BY s.starts_at
+	`
+
+	rows, err := r.pool.Query(context.Background(), query, userID, from)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	viewings := []domain.Viewing{}
+	for rows.Next() {
+		viewing, err := scanViewing(rows)
+		if err != nil {
+			return nil, err
+		}
+		viewings = append(viewings, *viewing)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return viewings, nil
+}
+
+func scanSlot(row pgx.Row) (*domain.ViewingSlot, error) {
+	var slot domain.ViewingSlot
+	err := row.Scan(&slot.ID, &slot.ListingID, &slot.StartsAt, &slot.EndsAt, &slot.IsBooked)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrSlotNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &slot, nil
+}
+
+func scanViewing(row pgx.Row) (*domain.Viewing, error) {
+	var viewing domain.Viewing
+	err := row.Scan(&viewing.ID, &viewing.SlotID, &viewing.ListingID, &viewing.ListingTitle, &viewing.ListingLocation,
+		&viewing.CustomerID, &viewing.CustomerName, &viewing.OwnerID, &viewing.OwnerName, &viewing.RoomID,
+		&viewing.StartsAt, &viewing.EndsAt, &viewing.Status, &viewing.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &viewing, nil
+}
@@ -0,0 +1,29 @@
+package usecases
+
+import (
+	"message-server/internal/domain"
+)
+
+type AmenityUseCase struct {
+	amenityRepo domain.AmenityRepository
+}
+
+func NewAmenityUseCase(amenityRepo domain.AmenityRepository) *AmenityUseCase {
+	return &AmenityUseCase{amenityRepo: amenityRepo}
+}
+
+func (s *AmenityUseCase) CreateAmenity(req *domain.AmenityRequest) (string, error) {
+	return s.amenityRepo.CreateAmenity(req)
+}
+
+func (s *AmenityUseCase) GetAmenities() ([]domain.Amenity, error) {
+	return s.amenityRepo.GetAmenities()
+}
+
+func (s *AmenityUseCase) UpdateAmenity(id string, req *domain.AmenityRequest) error {
+	return s.amenityRepo.UpdateAmenity(id, req)
+}
+
+func (s *AmenityUseCase) DeleteAmenity(id string) error {
+	return s.amenityRepo.DeleteAmenity(id)
+}
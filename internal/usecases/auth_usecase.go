@@ -3,6 +3,7 @@ package usecases
 import (
 	"message-server/internal/controller/auth"
 	"message-server/internal/domain"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 )
@@ -52,7 +53,7 @@ func (s *AuthUseCase) Login(req *domain.LoginRequest) (*domain.User, string, err
 			return nil, "", domain.ErrInvalidCredentials
 		}
 
-		token, err := auth.GenerateToken(user.Username, user.Email, user.ID)
+		token, err := auth.GenerateToken(user.Username, user.Email, user.ID, user.Role)
 		if err != nil {
 			return nil, "", err
 		}
@@ -69,7 +70,7 @@ func (s *AuthUseCase) Login(req *domain.LoginRequest) (*domain.User, string, err
 			return nil, "", domain.ErrInvalidCredentials
 		}
 
-		token, err := auth.GenerateToken(user.Username, user.Email, user.ID)
+		token, err := auth.GenerateToken(user.Username, user.Email, user.ID, user.Role)
 		if err != nil {
 			return nil, "", err
 		}
@@ -103,6 +104,12 @@ func (s *AuthUseCase) GetUserByID(id string) (*domain.User, error) {
 	return s.authRepo.GetUserByID(id)
 }
 
+// UseWSTicket marks a WebSocket ticket as used. It returns
+// domain.ErrTicketUsed on the second use.
+func (s *AuthUseCase) UseWSTicket(id string, expiresAt time.Time) error {
+	return s.authRepo.UseWSTicket(id, expiresAt)
+}
+
 func hashPassword(password string) (string, error) {
 	const cost = 12
 
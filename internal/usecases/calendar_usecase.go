@@ -0,0 +1,93 @@
+package usecases
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"message-server/internal/domain"
+	"message-server/pkg"
+)
+
+type CalendarUseCase struct {
+	calendarRepo domain.CalendarRepository
+	viewings     *ViewingUseCase
+}
+
+func NewCalendarUseCase(calendarRepo domain.CalendarRepository, viewings *ViewingUseCase) *CalendarUseCase {
+	return &CalendarUseCase{calendarRepo: calendarRepo, viewings: viewings}
+}
+
+// GetViewingCalendar renders a single viewing the user takes part in.
+func (s *CalendarUseCase) GetViewingCalendar(userID, viewingID string) ([]byte, error) {
+	viewing, err := s.viewings.GetViewing(userID, viewingID)
+	if err != nil {
+		return nil, err
+	}
+
+	return pkg.BuildICalendar("Viewing", []pkg.ICalEvent{viewingEvent(viewing, userID)}, s.viewings.now()), nil
+}
+
+// CreateFeedToken issues a new subscription token. A token handed out
+// earlier stops working.
+func (s *CalendarUseCase) CreateFeedToken(userID string) (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(raw)
+
+	if err := s.calendarRepo.SetFeedToken(userID, hashFeedToken(token)); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+func (s *CalendarUseCase) RevokeFeedToken(userID string) error {
+	return s.calendarRepo.DeleteFeedToken(userID)
+}
+
+// GetFeed lists the upcoming viewings across the token owner's listings.
+func (s *CalendarUseCase) GetFeed(token string) ([]byte, error) {
+	userID, err := s.calendarRepo.GetUserIDByFeedToken(hashFeedToken(token))
+	if err != nil {
+		return nil, err
+	}
+
+	viewings, err := s.viewings.GetUpcomingViewings(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	events := []pkg.ICalEvent{}
+	for i := range viewings {
+		if viewings[i].OwnerID == userID {
+			events = append(events, viewingEvent(&viewings[i], userID))
+		}
+	}
+
+	return pkg.BuildICalendar("Listing viewings", events, s.viewings.now()), nil
+}
+
+func viewingEvent(viewing *domain.Viewing, userID string) pkg.ICalEvent {
+	with := viewing.CustomerName
+	if userID == viewing.CustomerID {
+		with = viewing.OwnerName
+	}
+
+	return pkg.ICalEvent{
+		UID:         "viewing-" + viewing.ID,
+		Start:       viewing.StartsAt,
+		End:         viewing.EndsAt,
+		Summary:     "Viewing: " + viewing.ListingTitle,
+		Location:    viewing.ListingLocation,
+		Description: fmt.Sprintf("Viewing with %s.", with),
+		Cancelled:   viewing.Status == domain.ViewingStatusCancelled,
+	}
+}
+
+func hashFeedToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,120 @@
+package usecases
+
+import (
+	"message-server/internal/domain"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeCalendarRepository struct {
+	domain.CalendarRepository
+	tokens map[string]string
+}
+
+func (r *fakeCalendarRepository) SetFeedToken(userID, tokenHash string) error {
+	for hash, owner := range r.tokens {
+		if owner == userID {
+			delete(r.tokens, hash)
+		}
+	}
+	r.tokens[tokenHash] = userID
+	return nil
+}
+
+func (r *fakeCalendarRepository) DeleteFeedToken(userID string) error {
+	for hash, owner := range r.tokens {
+		if owner == userID {
+			delete(r.tokens, hash)
+			return nil
+		}
+	}
+	return domain.ErrCalendarFeedNotFound
+}
+
+func (r *fakeCalendarRepository) GetUserIDByFeedToken(tokenHash string) (string, error) {
+	userID, ok := r.tokens[tokenHash]
+	if !ok {
+		return "", domain.ErrCalendarFeedNotFound
+	}
+	return userID, nil
+}
+
+type upcomingViewingRepository struct {
+	domain.ViewingRepository
+	viewings []domain.Viewing
+}
+
+func (r *upcomingViewingRepository) GetUpcomingViewings(userID string, from time.Time) ([]domain.Viewing, error) {
+	return r.viewings, nil
+}
+
+func newTestCalendarUseCase() *CalendarUseCase {
+	viewings := &upcomingViewingRepository{viewings: []domain.Viewing{
+		{
+			ID: "viewing-1", ListingTitle: "Flat, with a view; top floor", ListingLocation: "Main St 1",
+			CustomerID: "customer", CustomerName: "Casey", OwnerID: "owner",
+			StartsAt: testNow.Add(time.Hour), EndsAt: testNow.Add(90 * time.Minute), Status: domain.ViewingStatusBooked,
+		},
+		{
+			ID: "viewing-2", ListingTitle: "Someone else's house", OwnerID: "other", CustomerID: "owner",
+			StartsAt: testNow.Add(time.Hour), EndsAt: testNow.Add(2 * time.Hour), Status: domain.ViewingStatusBooked,
+		},
+	}}
+
+	viewingUseCase := NewViewingUseCase(viewings, nil, nil)
+	viewingUseCase.now = func() time.Time { return testNow }
+	return NewCalendarUseCase(&fakeCalendarRepository{tokens: map[string]string{}}, viewingUseCase)
+}
+
+func TestCalendarUseCase_Feed(t *testing.T) {
+	useCase := newTestCalendarUseCase()
+
+	token, err := useCase.CreateFeedToken("owner")
+	if err != nil {
+		t.Fatalf("CreateFeedToken() error = %v", err)
+	}
+
+	feed, err := useCase.GetFeed(token)
+	if err != nil {
+		t.Fatalf("GetFeed() error = %v", err)
+	}
+
+	calendar := string(feed)
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"UID:viewing-viewing-1\r\n",
+		"DTSTART:20260501T130000Z\r\n",
+		"DTEND:20260501T133000Z\r\n",
+		`SUMMARY:Viewing: Flat\, with a view\; top floor` + "\r\n",
+		"LOCATION:Main St 1\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(calendar, want) {
+			t.Errorf("feed is missing %q:\n%s", want, calendar)
+		}
+	}
+	if strings.Contains(calendar, "viewing-2") {
+		t.Errorf("feed lists a viewing of another owner's listing")
+	}
+	for _, line := range strings.Split(calendar, "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line longer than 75 octets: %q", line)
+		}
+	}
+
+	rotated, err := useCase.CreateFeedToken("owner")
+	if err != nil {
+		t.Fatalf("CreateFeedToken() error = %v", err)
+	}
+	if _, err := useCase.GetFeed(token); err != domain.ErrCalendarFeedNotFound {
+		t.Errorf("rotated token error = %v, want %v", err, domain.ErrCalendarFeedNotFound)
+	}
+
+	if err := useCase.RevokeFeedToken("owner"); err != nil {
+		t.Fatalf("RevokeFeedToken() error = %v", err)
+	}
+	if _, err := useCase.GetFeed(rotated); err != domain.ErrCalendarFeedNotFound {
+		t.Errorf("revoked token error = %v, want %v", err, domain.ErrCalendarFeedNotFound)
+	}
+}
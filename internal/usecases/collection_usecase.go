@@ -0,0 +1,226 @@
+package usecases
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"message-server/internal/domain"
+	"message-server/pkg"
+	"strings"
+)
+
+type CollectionUseCase struct {
+	collectionRepo domain.CollectionRepository
+	authRepo       domain.AuthRepository
+	shareSecret    []byte
+}
+
+func NewCollectionUseCase(
+	collectionRepo domain.CollectionRepository,
+	authRepo domain.AuthRepository,
+	shareSecret []byte,
+) *CollectionUseCase {
+	return &CollectionUseCase{
+		collectionRepo: collectionRepo,
+		authRepo:       authRepo,
+		shareSecret:    shareSecret,
+	}
+}
+
+func (s *CollectionUseCase) CreateCollection(userID string, req *domain.CollectionRequest) (*domain.BookmarkCollection, error) {
+	return s.collectionRepo.CreateCollection(userID, req.Name)
+}
+
+func (s *CollectionUseCase) GetCollections(userID string) ([]domain.BookmarkCollection, error) {
+	return s.collectionRepo.GetCollections(userID)
+}
+
+func (s *CollectionUseCase) GetCollection(userID, id string) (*domain.GetCollectionResponse, error) {
+	collection, err := s.accessCollection(userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s.collectionRepo.GetCollectionItems(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	collaborators, err := s.collectionRepo.GetCollaborators(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.GetCollectionResponse{BookmarkCollection: *collection, Items: items, Collaborators: collaborators}, nil
+}
+
+func (s *CollectionUseCase) RenameCollection(userID, id string, req *domain.CollectionRequest) (*domain.BookmarkCollection, error) {
+	if _, err := s.ownCollection(userID, id); err != nil {
+		return nil, err
+	}
+
+	if err := s.collectionRepo.RenameCollection(id, req.Name); err != nil {
+		return nil, err
+	}
+
+	return s.collectionRepo.GetCollection(id)
+}
+
+func (s *CollectionUseCase) DeleteCollection(userID, id string) error {
+	if _, err := s.ownCollection(userID, id); err != nil {
+		return err
+	}
+
+	return s.collectionRepo.DeleteCollection(id)
+}
+
+func (s *CollectionUseCase) SaveCollectionItem(userID, collectionID, listingID string, req *domain.CollectionItemRequest) error {
+	if _, err := s.accessCollection(userID, collectionID); err != nil {
+		return err
+	}
+
+	return s.collectionRepo.SaveCollectionItem(collectionID, listingID, userID, req.Note)
+}
+
+func (s *CollectionUseCase) RemoveCollectionItem(userID, collectionID, listingID string) error {
+	if _, err := s.accessCollection(userID, collectionID); err != nil {
+		return err
+	}
+
+	return s.collectionRepo.RemoveCollectionItem(collectionID, listingID)
+}
+
+func (s *CollectionUseCase) AddCollaborator(userID, collectionID string, req *domain.CollaboratorRequest) ([]domain.CollectionCollaborator, error) {
+	collection, err := s.ownCollection(userID, collectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.authRepo.GetUserByUsername(req.Username)
+	if err != nil {
+		return nil, domain.ErrUserNotFound
+	}
+	if user.ID == collection.OwnerID {
+		return nil, domain.ErrCollaboratorIsOwner
+	}
+
+	if err := s.collectionRepo.AddCollaborator(collectionID, user.ID); err != nil {
+		return nil, err
+	}
+
+	return s.collectionRepo.GetCollaborators(collectionID)
+}
+
+// RemoveCollaborator lets the owner remove anyone, and a collaborator leave
+// the collection.
+func (s *CollectionUseCase) RemoveCollaborator(userID, collectionID, collaboratorID string) error {
+	collection, err := s.accessCollection(userID, collectionID)
+	if err != nil {
+		return err
+	}
+
+	if collection.OwnerID != userID && collaboratorID != userID {
+		return domain.ErrForbidden
+	}
+
+	return s.collectionRepo.RemoveCollaborator(collectionID, collaboratorID)
+}
+
+// ShareCollection issues a new read-only link. Links handed out earlier stop
+// working.
+func (s *CollectionUseCase) ShareCollection(userID, id string) (*domain.ShareCollectionResponse, error) {
+	if _, err := s.ownCollection(userID, id); err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	encodedNonce := hex.EncodeToString(nonce)
+
+	if err := s.collectionRepo.SetShareNonce(id, &encodedNonce); err != nil {
+		return nil, err
+	}
+
+	token := pkg.SignToken(shareTokenPrefix+id+":"+encodedNonce, s.shareSecret)
+	return &domain.ShareCollectionResponse{Token: token}, nil
+}
+
+func (s *CollectionUseCase) UnshareCollection(userID, id string) error {
+	if _, err := s.ownCollection(userID, id); err != nil {
+		return err
+	}
+
+	return s.collectionRepo.SetShareNonce(id, nil)
+}
+
+// GetSharedCollection resolves a share link. Collaborators are not exposed.
+func (s *CollectionUseCase) GetSharedCollection(token, viewerID string) (*domain.GetCollectionResponse, error) {
+	payload, err := pkg.VerifyToken(token, s.shareSecret)
+	if err != nil {
+		return nil, domain.ErrInvalidShareToken
+	}
+
+	id, nonce, found := strings.Cut(strings.TrimPrefix(payload, shareTokenPrefix), ":")
+	if !found || !strings.HasPrefix(payload, shareTokenPrefix) {
+		return nil, domain.ErrInvalidShareToken
+	}
+
+	collection, err := s.collectionRepo.GetCollection(id)
+	if err == domain.ErrCollectionNotFound {
+		return nil, domain.ErrInvalidShareToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if collection.ShareNonce == nil || *collection.ShareNonce != nonce {
+		return nil, domain.ErrInvalidShareToken
+	}
+
+	items, err := s.collectionRepo.GetCollectionItems(id, viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.GetCollectionResponse{BookmarkCollection: *collection, Items: items}, nil
+}
+
+// shareTokenPrefix keeps share tokens from being accepted anywhere else the
+// same secret is used.
+const shareTokenPrefix = "collection:"
+
+// accessCollection loads a collection the user owns or collaborates on.
+// Anyone else gets ErrCollectionNotFound so private collections don't leak.
+func (s *CollectionUseCase) accessCollection(userID, id string) (*domain.BookmarkCollection, error) {
+	collection, err := s.collectionRepo.GetCollection(id)
+	if err != nil {
+		return nil, err
+	}
+	if collection.OwnerID == userID {
+		return collection, nil
+	}
+
+	isCollaborator, err := s.collectionRepo.IsCollaborator(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !isCollaborator {
+		return nil, domain.ErrCollectionNotFound
+	}
+
+	return collection, nil
+}
+
+// ownCollection is accessCollection restricted to the owner; collaborators
+// get ErrForbidden.
+func (s *CollectionUseCase) ownCollection(userID, id string) (*domain.BookmarkCollection, error) {
+	collection, err := s.accessCollection(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if collection.OwnerID != userID {
+		return nil, domain.ErrForbidden
+	}
+
+	return collection, nil
+}
@@ -0,0 +1,111 @@
+package usecases
+
+import (
+	"errors"
+	"message-server/internal/domain"
+	"testing"
+)
+
+type fakeCollectionRepository struct {
+	domain.CollectionRepository
+	collection    *domain.BookmarkCollection
+	collaborators map[string]bool
+}
+
+func (r *fakeCollectionRepository) GetCollection(id string) (*domain.BookmarkCollection, error) {
+	if id != r.collection.ID {
+		return nil, domain.ErrCollectionNotFound
+	}
+	collection := *r.collection
+	return &collection, nil
+}
+
+func (r *fakeCollectionRepository) IsCollaborator(collectionID, userID string) (bool, error) {
+	return r.collaborators[userID], nil
+}
+
+func (r *fakeCollectionRepository) SetShareNonce(id string, nonce *string) error {
+	r.collection.ShareNonce = nonce
+	return nil
+}
+
+func (r *fakeCollectionRepository) GetCollectionItems(id, viewerID string) ([]domain.CollectionItem, error) {
+	return []domain.CollectionItem{}, nil
+}
+
+func (r *fakeCollectionRepository) SaveCollectionItem(collectionID, listingID, userID, note string) error {
+	return nil
+}
+
+func newFakeCollectionRepository() *fakeCollectionRepository {
+	return &fakeCollectionRepository{
+		collection:    &domain.BookmarkCollection{ID: "collection-1", OwnerID: "owner"},
+		collaborators: map[string]bool{"partner": true},
+	}
+}
+
+func TestCollectionUseCase_Access(t *testing.T) {
+	tests := []struct {
+		name        string
+		userID      string
+		wantSaveErr error
+		wantOwnErr  error
+	}{
+		{name: "owner", userID: "owner"},
+		{name: "collaborator", userID: "partner", wantOwnErr: domain.ErrForbidden},
+		{name: "stranger", userID: "stranger", wantSaveErr: domain.ErrCollectionNotFound, wantOwnErr: domain.ErrCollectionNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useCase := NewCollectionUseCase(newFakeCollectionRepository(), nil, []byte("secret"))
+
+			err := useCase.SaveCollectionItem(tt.userID, "collection-1", "listing-1", &domain.CollectionItemRequest{})
+			if !errors.Is(err, tt.wantSaveErr) {
+				t.Errorf("SaveCollectionItem() error = %v, want %v", err, tt.wantSaveErr)
+			}
+
+			_, err = useCase.ShareCollection(tt.userID, "collection-1")
+			if !errors.Is(err, tt.wantOwnErr) {
+				t.Errorf("ShareCollection() error = %v, want %v", err, tt.wantOwnErr)
+			}
+		})
+	}
+}
+
+func TestCollectionUseCase_ShareLinks(t *testing.T) {
+	repo := newFakeCollectionRepository()
+	useCase := NewCollectionUseCase(repo, nil, []byte("secret"))
+
+	first, err := useCase.ShareCollection("owner", "collection-1")
+	if err != nil {
+		t.Fatalf("ShareCollection() error = %v", err)
+	}
+	if _, err := useCase.GetSharedCollection(first.Token, ""); err != nil {
+		t.Fatalf("GetSharedCollection() error = %v", err)
+	}
+
+	if _, err := useCase.GetSharedCollection(first.Token+"x", ""); err != domain.ErrInvalidShareToken {
+		t.Errorf("tampered token error = %v, want %v", err, domain.ErrInvalidShareToken)
+	}
+
+	forged := NewCollectionUseCase(repo, nil, []byte("other secret"))
+	if _, err := forged.GetSharedCollection(first.Token, ""); err != domain.ErrInvalidShareToken {
+		t.Errorf("token signed with another secret error = %v, want %v", err, domain.ErrInvalidShareToken)
+	}
+
+	second, err := useCase.ShareCollection("owner", "collection-1")
+	if err != nil {
+		t.Fatalf("ShareCollection() error = %v", err)
+	}
+	if _, err := useCase.GetSharedCollection(first.Token, ""); err != domain.ErrInvalidShareToken {
+		t.Errorf("rotated token error = %v, want %v", err, domain.ErrInvalidShareToken)
+	}
+
+	if err := useCase.UnshareCollection("owner", "collection-1"); err != nil {
+		t.Fatalf("UnshareCollection() error = %v", err)
+	}
+	if _, err := useCase.GetSharedCollection(second.Token, ""); err != domain.ErrInvalidShareToken {
+		t.Errorf("revoked token error = %v, want %v", err, domain.ErrInvalidShareToken)
+	}
+}
@@ -0,0 +1,18 @@
+package usecases
+
+import (
+	"context"
+	"message-server/internal/domain"
+)
+
+// runExclusively runs job unless another instance holds the lock for name,
+// in which case that instance does the work and this run is skipped.
+func runExclusively(lock domain.JobLock, name string, job func() error) error {
+	release, locked, err := lock.TryLock(context.Background(), name)
+	if err != nil || !locked {
+		return err
+	}
+	defer release()
+
+	return job()
+}
@@ -0,0 +1,46 @@
+package usecases
+
+import (
+	"context"
+	"testing"
+)
+
+type fakeJobLock struct {
+	held     map[string]bool
+	released []string
+}
+
+func (l *fakeJobLock) TryLock(ctx context.Context, name string) (func(), bool, error) {
+	if l.held[name] {
+		return nil, false, nil
+	}
+	l.held[name] = true
+	return func() {
+		l.held[name] = false
+		l.released = append(l.released, name)
+	}, true, nil
+}
+
+func TestRunExclusively(t *testing.T) {
+	lock := &fakeJobLock{held: map[string]bool{"busy": true}}
+
+	ran := 0
+	job := func() error {
+		ran++
+		return nil
+	}
+
+	if err := runExclusively(lock, "busy", job); err != nil {
+		t.Fatalf("runExclusively() error = %v", err)
+	}
+	if ran != 0 {
+		t.Errorf("job ran %d times while another instance held the lock, want 0", ran)
+	}
+
+	if err := runExclusively(lock, "free", job); err != nil {
+		t.Fatalf("runExclusively() error = %v", err)
+	}
+	if ran != 1 || len(lock.released) != 1 || lock.held["free"] {
+		t.Errorf("ran = %d, released = %v, want one run that released the lock", ran, lock.released)
+	}
+}
@@ -1,36 +1,297 @@
 package usecases
 
 import (
+	"fmt"
+	"math"
 	"message-server/internal/domain"
+	"message-server/pkg"
+	"slices"
+	"strconv"
+	"strings"
 )
 
 type ListingUseCase struct {
-	listingRepo domain.ListingRepository
-	fileRepo    domain.FileRepository
+	listingRepo   domain.ListingRepository
+	fileRepo      domain.FileRepository
+	notifications *NotificationUseCase
 }
 
-func NewListingUseCase(listingRepo domain.ListingRepository, fileRepo domain.FileRepository) *ListingUseCase {
-	return &ListingUseCase{listingRepo: listingRepo, fileRepo: fileRepo}
+func NewListingUseCase(
+	listingRepo domain.ListingRepository,
+	fileRepo domain.FileRepository,
+	notifications *NotificationUseCase,
+) *ListingUseCase {
+	return &ListingUseCase{
+		listingRepo:   listingRepo,
+		fileRepo:      fileRepo,
+		notifications: notifications,
+	}
 }
 
 func (s *ListingUseCase) CreateListing(request *domain.CreateListingRequest) (string, error) {
+	if request.Status == "" {
+		request.Status = domain.ListingStatusPublished
+	}
+
 	return s.listingRepo.CreateListing(request)
 }
 
-func (s *ListingUseCase) GetListingByID(id string) (*domain.GetListingDetailsResponse, error) {
-	return s.listingRepo.GetListingByID(id)
+// GetListingByID returns the listing as seen by viewer, which is nil for
+// anonymous requests. Listings hidden from the catalogue are only shown to
+// their owner and admins.
+func (s *ListingUseCase) GetListingByID(id string, viewer *domain.Actor) (*domain.GetListingDetailsResponse, error) {
+	listing, err := visibleListing(s.listingRepo, id, viewer)
+	if err != nil {
+		return nil, err
+	}
+
+	if viewer != nil {
+		listing.IsBookmarked, err = s.listingRepo.IsBookmarked(viewer.UserID, id)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return listing, nil
+}
+
+// GetListings searches the public catalogue: drafts and archived listings are
+// never returned.
+func (s *ListingUseCase) GetListings(filter *domain.ListingFilter) (*domain.GetListingsResponse, error) {
+	filter.Statuses = domain.PublicListingStatuses
+	return s.searchListings(filter)
+}
+
+// GetMyListings returns every listing owned by the user, whatever its status.
+func (s *ListingUseCase) GetMyListings(userID string, filter *domain.ListingFilter) (*domain.GetListingsResponse, error) {
+	filter.OwnerID = userID
+	filter.Statuses = nil
+	return s.searchListings(filter)
+}
+
+func (s *ListingUseCase) searchListings(filter *domain.ListingFilter) (*domain.GetListingsResponse, error) {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	filter.Limit = clampLimit(filter.Limit, domain.DefaultListingsLimit, domain.MaxListingsLimit)
+
+	if filter.Sort == "" {
+		filter.Sort = "created_at"
+		if filter.Q != "" {
+			filter.Sort = "relevance"
+		}
+	}
+	if filter.Sort == "relevance" && filter.Q == "" {
+		filter.Sort = "created_at"
+	}
+
+	if filter.BBox != "" {
+		bounds, err := parseBoundingBox(filter.BBox)
+		if err != nil {
+			return nil, err
+		}
+		filter.Bounds = bounds
+	}
+
+	if err := checkAvailability(filter); err != nil {
+		return nil, err
+	}
+
+	if filter.Sort == "distance" && (filter.Lat == nil || filter.Lng == nil) {
+		return nil, domain.ErrMissingCoordinates
+	}
+
+	if filter.Cursor != "" {
+		if filter.Sort != "created_at" {
+			return nil, domain.ErrInvalidCursor
+		}
+
+		after, err := decodeCursor(filter.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		filter.After = after
+		filter.Page = 1
+	}
+
+	response, err := s.listingRepo.GetListings(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	response.Page = filter.Page
+	response.Limit = filter.Limit
+	response.TotalPages = (response.Total + filter.Limit - 1) / filter.Limit
+	return response, nil
+}
+
+// GetNearbyListings is GetListings anchored on a point: the radius defaults to
+// DefaultNearbyRadiusKm and results are sorted by distance unless asked
+// otherwise.
+func (s *ListingUseCase) GetNearbyListings(filter *domain.ListingFilter) (*domain.GetListingsResponse, error) {
+	if filter.Lat == nil || filter.Lng == nil {
+		return nil, domain.ErrMissingCoordinates
+	}
+
+	if filter.RadiusKm == nil {
+		radius := domain.DefaultNearbyRadiusKm
+		filter.RadiusKm = &radius
+	}
+	if filter.Sort == "" {
+		filter.Sort = "distance"
+	}
+
+	return s.GetListings(filter)
+}
+
+// clusterCellPixels is the on-screen size of a cluster cell, assuming the
+// usual 256px web mercator tiles.
+const clusterCellPixels = 64
+
+func (s *ListingUseCase) GetListingClusters(filter *domain.ListingClusterFilter) (*domain.GetListingClustersResponse, error) {
+	if filter.BBox == "" {
+		return nil, domain.ErrInvalidBoundingBox
+	}
+
+	bounds, err := parseBoundingBox(filter.BBox)
+	if err != nil {
+		return nil, err
+	}
+	filter.Bounds = bounds
+	filter.Statuses = domain.PublicListingStatuses
+
+	if err := checkAvailability(&filter.ListingFilter); err != nil {
+		return nil, err
+	}
+
+	cellSize := 360.0 * clusterCellPixels / (256 * math.Pow(2, float64(*filter.Zoom)))
+	return s.listingRepo.GetListingClusters(&filter.ListingFilter, cellSize)
+}
+
+func (s *ListingUseCase) UpdateListing(actor *domain.Actor, listing *domain.Listing, expectedVersion *int) error {
+	current, err := s.authorizeListingOwner(actor, listing.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.listingRepo.UpdateListing(listing, expectedVersion); err != nil {
+		return err
+	}
+
+	s.notifyPriceDrop(current, listing.Title, listing.Price)
+	return nil
+}
+
+func (s *ListingUseCase) PatchListing(actor *domain.Actor, id string, request *domain.UpdateListingRequest, expectedVersion *int) (*domain.GetListingDetailsResponse, error) {
+	current, err := s.authorizeListingOwner(actor, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.listingRepo.PatchListing(id, request, expectedVersion); err != nil {
+		return nil, err
+	}
+
+	updated, err := s.listingRepo.GetListingByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.notifyPriceDrop(current, updated.Title, updated.Price)
+	return updated, nil
 }
 
-func (s *ListingUseCase) GetListings() (*domain.GetListingsResponse, error) {
-	return s.listingRepo.GetListings()
+func (s *ListingUseCase) GetPriceHistory(listingID string, viewer *domain.Actor) ([]domain.PriceChange, error) {
+	if _, err := visibleListing(s.listingRepo, listingID, viewer); err != nil {
+		return nil, err
+	}
+
+	return s.listingRepo.GetPriceHistory(listingID)
+}
+
+// listingTransitions lists the statuses each status may move to.
+var listingTransitions = map[string][]string{
+	domain.ListingStatusDraft:      {domain.ListingStatusPublished, domain.ListingStatusArchived},
+	domain.ListingStatusPublished:  {domain.ListingStatusDraft, domain.ListingStatusUnderOffer, domain.ListingStatusSold, domain.ListingStatusRented, domain.ListingStatusArchived},
+	domain.ListingStatusUnderOffer: {domain.ListingStatusPublished, domain.ListingStatusSold, domain.ListingStatusRented, domain.ListingStatusArchived},
+	domain.ListingStatusSold:       {domain.ListingStatusArchived},
+	domain.ListingStatusRented:     {domain.ListingStatusPublished, domain.ListingStatusArchived},
+	domain.ListingStatusArchived:   {domain.ListingStatusDraft, domain.ListingStatusPublished},
+}
+
+func (s *ListingUseCase) PublishListing(actor *domain.Actor, id string) (*domain.GetListingDetailsResponse, error) {
+	return s.transitionListing(actor, id, func(*domain.GetListingDetailsResponse) string {
+		return domain.ListingStatusPublished
+	})
 }
 
-func (s *ListingUseCase) UpdateListing(listing *domain.Listing) error {
-	return s.listingRepo.UpdateListing(listing)
+func (s *ListingUseCase) ArchiveListing(actor *domain.Actor, id string) (*domain.GetListingDetailsResponse, error) {
+	return s.transitionListing(actor, id, func(*domain.GetListingDetailsResponse) string {
+		return domain.ListingStatusArchived
+	})
 }
 
-func (s *ListingUseCase) DeleteListing(id string) error {
+// MarkListingSold closes the deal: rental listings become rented, everything
+// else sold.
+func (s *ListingUseCase) MarkListingSold(actor *domain.Actor, id string) (*domain.GetListingDetailsResponse, error) {
+	return s.transitionListing(actor, id, func(listing *domain.GetListingDetailsResponse) string {
+		if listing.Type == domain.ListingTypeRent {
+			return domain.ListingStatusRented
+		}
+		return domain.ListingStatusSold
+	})
+}
+
+// NotifyStatusChange tells bookmarkers about a status change saved
+// elsewhere, such as an accepted offer. Failures are only logged.
+func (s *ListingUseCase) NotifyStatusChange(id, status string) {
 	listing, err := s.listingRepo.GetListingByID(id)
+	if err != nil {
+		pkg.Logger.Printf("Failed to load listing %s for status notification: %v", id, err)
+		return
+	}
+
+	s.notifyStatusChange(id, listing.Title, status)
+}
+
+func (s *ListingUseCase) transitionListing(actor *domain.Actor, id string, target func(*domain.GetListingDetailsResponse) string) (*domain.GetListingDetailsResponse, error) {
+	listing, err := s.authorizeListingOwner(actor, id)
+	if err != nil {
+		return nil, err
+	}
+
+	status := target(listing)
+	if !slices.Contains(listingTransitions[listing.Status], status) {
+		return nil, domain.ErrInvalidTransition
+	}
+
+	if err := s.listingRepo.UpdateListingStatus(id, status); err != nil {
+		return nil, err
+	}
+
+	s.notifyStatusChange(id, listing.Title, status)
+
+	return s.listingRepo.GetListingByID(id)
+}
+
+func (s *ListingUseCase) notifyStatusChange(id, title, status string) {
+	s.notifyBookmarkers(id, &domain.Notification{
+		Type:      domain.NotificationStatusChange,
+		ListingID: &id,
+		Title:     "Listing status changed",
+		Body:      fmt.Sprintf("%s is now %s.", title, strings.ReplaceAll(status, "_", " ")),
+	})
+}
+
+func (s *ListingUseCase) DeleteListing(actor *domain.Actor, id string) error {
+	listing, err := s.authorizeListingOwner(actor, id)
+	if err != nil {
+		return err
+	}
+
+	// Bookmarks are deleted along with the listing, so look up who to tell
+	// beforehand.
+	subscribers, err := s.listingRepo.GetBookmarkSubscribers(id)
 	if err != nil {
 		return err
 	}
@@ -42,7 +303,16 @@ func (s *ListingUseCase) DeleteListing(id string) error {
 		}
 	}
 
-	return s.listingRepo.DeleteListing(id)
+	if err := s.listingRepo.DeleteListing(id); err != nil {
+		return err
+	}
+
+	s.notifications.Notify(subscribers, &domain.Notification{
+		Type:  domain.NotificationListingDeleted,
+		Title: "Listing removed",
+		Body:  fmt.Sprintf("%s has been removed.", listing.Title),
+	})
+	return nil
 }
 
 func (s *ListingUseCase) BookmarkListing(userID, listingID string) error {
@@ -53,6 +323,110 @@ func (s *ListingUseCase) UnbookmarkListing(userID, listingID string) error {
 	return s.listingRepo.UnbookmarkListing(userID, listingID)
 }
 
-func (s *ListingUseCase) GetBookmarkedListings(userID string) ([]domain.ListingInfo, error) {
-	return s.listingRepo.GetBookmarkedListings(userID)
+func (s *ListingUseCase) SetBookmarkNotify(userID, listingID string, notify bool) error {
+	return s.listingRepo.SetBookmarkNotify(userID, listingID, notify)
+}
+
+func (s *ListingUseCase) GetBookmarkedListings(userID string, query *domain.CursorQuery) (*domain.GetBookmarkedListingsResponse, error) {
+	after, err := decodeCursor(query.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := clampLimit(query.Limit, domain.DefaultListingsLimit, domain.MaxListingsLimit)
+	return s.listingRepo.GetBookmarkedListings(userID, after, limit)
+}
+
+// visibleListing loads a listing for a public read. Drafts and archived
+// listings are reported as missing to everyone but their owner and admins.
+func visibleListing(repo domain.ListingRepository, id string, viewer *domain.Actor) (*domain.GetListingDetailsResponse, error) {
+	listing, err := repo.GetListingByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !listing.IsVisibleTo(viewer) {
+		return nil, domain.ErrListingNotFound
+	}
+
+	return listing, nil
+}
+
+// authorizeListingOwner loads the listing and checks that the actor owns it
+// or is an admin.
+func (s *ListingUseCase) authorizeListingOwner(actor *domain.Actor, listingID string) (*domain.GetListingDetailsResponse, error) {
+	listing, err := s.listingRepo.GetListingByID(listingID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !actor.CanModify(listing.UserID) {
+		return nil, domain.ErrForbidden
+	}
+
+	return listing, nil
+}
+
+// notifyPriceDrop tells bookmarkers when the price went down. Price rises are
+// not worth an interruption.
+func (s *ListingUseCase) notifyPriceDrop(previous *domain.GetListingDetailsResponse, title string, price int) {
+	if price >= previous.Price {
+		return
+	}
+
+	s.notifyBookmarkers(previous.ID, &domain.Notification{
+		Type:      domain.NotificationPriceDrop,
+		ListingID: &previous.ID,
+		Title:     "Price drop",
+		Body:      fmt.Sprintf("%s dropped from %d to %d.", title, previous.Price, price),
+	})
+}
+
+func (s *ListingUseCase) notifyBookmarkers(listingID string, notification *domain.Notification) {
+	subscribers, err := s.listingRepo.GetBookmarkSubscribers(listingID)
+	if err != nil {
+		pkg.Logger.Printf("Failed to load bookmark subscribers for listing %s: %v", listingID, err)
+		return
+	}
+
+	s.notifications.Notify(subscribers, notification)
+}
+
+func parseBoundingBox(bbox string) (*domain.BoundingBox, error) {
+	parts := strings.Split(bbox, ",")
+	if len(parts) != 4 {
+		return nil, domain.ErrInvalidBoundingBox
+	}
+
+	var values [4]float64
+	for i, part := range parts {
+		value, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, domain.ErrInvalidBoundingBox
+		}
+		values[i] = value
+	}
+
+	box := &domain.BoundingBox{MinLng: values[0], MinLat: values[1], MaxLng: values[2], MaxLat: values[3]}
+	if box.MinLat > box.MaxLat ||
+		box.MinLat < -90 || box.MaxLat > 90 ||
+		box.MinLng < -180 || box.MinLng > 180 ||
+		box.MaxLng < -180 || box.MaxLng > 180 {
+		return nil, domain.ErrInvalidBoundingBox
+	}
+
+	return box, nil
+}
+
+// checkAvailability validates the available_from/available_to pair, which is
+// only applied when both are set.
+func checkAvailability(filter *domain.ListingFilter) error {
+	if filter.AvailableFrom == "" && filter.AvailableTo == "" {
+		return nil
+	}
+	if filter.AvailableFrom == "" || filter.AvailableTo == "" {
+		return domain.ErrIncompleteDates
+	}
+
+	_, _, err := parseDateRange(filter.AvailableFrom, filter.AvailableTo)
+	return err
 }
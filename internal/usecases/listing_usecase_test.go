@@ -1 +1,458 @@
 package usecases
+
+import (
+	"errors"
+	"message-server/internal/domain"
+	"testing"
+)
+
+// fakeListingRepository embeds the interface so tests only implement the
+// methods they exercise; anything else panics.
+type fakeListingRepository struct {
+	domain.ListingRepository
+	listings    map[string]*domain.GetListingDetailsResponse
+	bookmarks   map[string]bool
+	subscribers []string
+	updated     []*domain.Listing
+	deleted     []string
+	filters     []*domain.ListingFilter
+}
+
+func newFakeListingRepository(listings ...*domain.GetListingDetailsResponse) *fakeListingRepository {
+	repo := &fakeListingRepository{listings: map[string]*domain.GetListingDetailsResponse{}}
+	for _, listing := range listings {
+		repo.listings[listing.ID] = listing
+	}
+	return repo
+}
+
+func (r *fakeListingRepository) GetListingByID(id string) (*domain.GetListingDetailsResponse, error) {
+	listing, ok := r.listings[id]
+	if !ok {
+		return nil, domain.ErrListingNotFound
+	}
+	return listing, nil
+}
+
+func (r *fakeListingRepository) GetListings(filter *domain.ListingFilter) (*domain.GetListingsResponse, error) {
+	r.filters = append(r.filters, filter)
+
+	response := &domain.GetListingsResponse{Listings: []domain.ListingInfo{}}
+	for _, listing := range r.listings {
+		if filter.OwnerID == "" || listing.UserID == filter.OwnerID {
+			response.Listings = append(response.Listings, domain.ListingInfo{ID: listing.ID})
+		}
+	}
+	response.Total = len(response.Listings)
+	return response, nil
+}
+
+func (r *fakeListingRepository) UpdateListing(listing *domain.Listing, expectedVersion *int) error {
+	if expectedVersion != nil && *expectedVersion != r.listings[listing.ID].Version {
+		return domain.ErrVersionConflict
+	}
+	r.updated = append(r.updated, listing)
+	return nil
+}
+
+func (r *fakeListingRepository) UpdateListingStatus(id, status string) error {
+	r.listings[id].Status = status
+	return nil
+}
+
+func (r *fakeListingRepository) GetPriceHistory(listingID string) ([]domain.PriceChange, error) {
+	return []domain.PriceChange{}, nil
+}
+
+func (r *fakeListingRepository) IsBookmarked(userID, listingID string) (bool, error) {
+	return r.bookmarks[userID+"/"+listingID], nil
+}
+
+func (r *fakeListingRepository) GetBookmarkSubscribers(listingID string) ([]string, error) {
+	return r.subscribers, nil
+}
+
+func (r *fakeListingRepository) DeleteListing(id string) error {
+	r.deleted = append(r.deleted, id)
+	delete(r.listings, id)
+	return nil
+}
+
+type fakeFileRepository struct {
+	domain.FileRepository
+	deleted []string
+}
+
+func (r *fakeFileRepository) DeleteFile(key string) error {
+	r.deleted = append(r.deleted, key)
+	return nil
+}
+
+type fakeNotificationRepository struct {
+	domain.NotificationRepository
+	created []domain.Notification
+}
+
+func (r *fakeNotificationRepository) CreateNotifications(userIDs []string, notification *domain.Notification) ([]domain.Notification, error) {
+	var created []domain.Notification
+	for _, userID := range userIDs {
+		n := *notification
+		n.UserID = userID
+		created = append(created, n)
+	}
+	r.created = append(r.created, created...)
+	return created, nil
+}
+
+func newTestListingUseCase(repo *fakeListingRepository, files *fakeFileRepository) (*ListingUseCase, *fakeNotificationRepository) {
+	notifications := &fakeNotificationRepository{}
+	return NewListingUseCase(repo, files, NewNotificationUseCase(notifications)), notifications
+}
+
+func TestListingUseCase_UpdateListing_Ownership(t *testing.T) {
+	tests := []struct {
+		name      string
+		actor     *domain.Actor
+		listingID string
+		wantErr   error
+	}{
+		{name: "owner", actor: &domain.Actor{UserID: "owner"}, listingID: "listing-1"},
+		{name: "other user", actor: &domain.Actor{UserID: "intruder"}, listingID: "listing-1", wantErr: domain.ErrForbidden},
+		{name: "admin", actor: &domain.Actor{UserID: "admin", IsAdmin: true}, listingID: "listing-1"},
+		{name: "missing listing", actor: &domain.Actor{UserID: "owner"}, listingID: "missing", wantErr: domain.ErrListingNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeListingRepository(&domain.GetListingDetailsResponse{ID: "listing-1", UserID: "owner"})
+			useCase, _ := newTestListingUseCase(repo, &fakeFileRepository{})
+
+			err := useCase.UpdateListing(tt.actor, &domain.Listing{ID: tt.listingID, Title: "New title"}, nil)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UpdateListing() error = %v, want %v", err, tt.wantErr)
+			}
+
+			wantUpdates := 0
+			if tt.wantErr == nil {
+				wantUpdates = 1
+			}
+			if len(repo.updated) != wantUpdates {
+				t.Errorf("repository updates = %d, want %d", len(repo.updated), wantUpdates)
+			}
+		})
+	}
+}
+
+func TestListingUseCase_DeleteListing_Ownership(t *testing.T) {
+	tests := []struct {
+		name      string
+		actor     *domain.Actor
+		listingID string
+		wantErr   error
+	}{
+		{name: "owner", actor: &domain.Actor{UserID: "owner"}, listingID: "listing-1"},
+		{name: "other user", actor: &domain.Actor{UserID: "intruder"}, listingID: "listing-1", wantErr: domain.ErrForbidden},
+		{name: "admin", actor: &domain.Actor{UserID: "admin", IsAdmin: true}, listingID: "listing-1"},
+		{name: "missing listing", actor: &domain.Actor{UserID: "owner"}, listingID: "missing", wantErr: domain.ErrListingNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeListingRepository(&domain.GetListingDetailsResponse{
+				ID:        "listing-1",
+				UserID:    "owner",
+				ImageKeys: []string{"image-1", "image-2"},
+			})
+			files := &fakeFileRepository{}
+			useCase, _ := newTestListingUseCase(repo, files)
+
+			err := useCase.DeleteListing(tt.actor, tt.listingID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("DeleteListing() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr != nil {
+				if len(repo.deleted) != 0 || len(files.deleted) != 0 {
+					t.Errorf("rejected delete touched storage: listings=%v files=%v", repo.deleted, files.deleted)
+				}
+				return
+			}
+
+			if len(repo.deleted) != 1 || repo.deleted[0] != tt.listingID {
+				t.Errorf("deleted listings = %v, want [%s]", repo.deleted, tt.listingID)
+			}
+			if len(files.deleted) != 2 {
+				t.Errorf("deleted files = %v, want both images", files.deleted)
+			}
+		})
+	}
+}
+
+func TestListingUseCase_StatusTransitions(t *testing.T) {
+	owner := &domain.Actor{UserID: "owner"}
+
+	tests := []struct {
+		name        string
+		listingType string
+		from        string
+		change      func(*ListingUseCase) (*domain.GetListingDetailsResponse, error)
+		wantStatus  string
+		wantErr     error
+	}{
+		{
+			name: "publish draft", listingType: "sale", from: domain.ListingStatusDraft,
+			change: func(u *ListingUseCase) (*domain.GetListingDetailsResponse, error) {
+				return u.PublishListing(owner, "listing-1")
+			},
+			wantStatus: domain.ListingStatusPublished,
+		},
+		{
+			name: "sell published sale", listingType: "sale", from: domain.ListingStatusPublished,
+			change: func(u *ListingUseCase) (*domain.GetListingDetailsResponse, error) {
+				return u.MarkListingSold(owner, "listing-1")
+			},
+			wantStatus: domain.ListingStatusSold,
+		},
+		{
+			name: "mark rental as rented", listingType: "rent", from: domain.ListingStatusUnderOffer,
+			change: func(u *ListingUseCase) (*domain.GetListingDetailsResponse, error) {
+				return u.MarkListingSold(owner, "listing-1")
+			},
+			wantStatus: domain.ListingStatusRented,
+		},
+		{
+			name: "archive sold", listingType: "sale", from: domain.ListingStatusSold,
+			change: func(u *ListingUseCase) (*domain.GetListingDetailsResponse, error) {
+				return u.ArchiveListing(owner, "listing-1")
+			},
+			wantStatus: domain.ListingStatusArchived,
+		},
+		{
+			name: "sell draft", listingType: "sale", from: domain.ListingStatusDraft,
+			change: func(u *ListingUseCase) (*domain.GetListingDetailsResponse, error) {
+				return u.MarkListingSold(owner, "listing-1")
+			},
+			wantErr: domain.ErrInvalidTransition,
+		},
+		{
+			name: "republish sold", listingType: "sale", from: domain.ListingStatusSold,
+			change: func(u *ListingUseCase) (*domain.GetListingDetailsResponse, error) {
+				return u.PublishListing(owner, "listing-1")
+			},
+			wantErr: domain.ErrInvalidTransition,
+		},
+		{
+			name: "archive someone else's listing", listingType: "sale", from: domain.ListingStatusPublished,
+			change: func(u *ListingUseCase) (*domain.GetListingDetailsResponse, error) {
+				return u.ArchiveListing(&domain.Actor{UserID: "intruder"}, "listing-1")
+			},
+			wantErr: domain.ErrForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeListingRepository(&domain.GetListingDetailsResponse{
+				ID:     "listing-1",
+				UserID: "owner",
+				Type:   tt.listingType,
+				Status: tt.from,
+			})
+			useCase, _ := newTestListingUseCase(repo, &fakeFileRepository{})
+
+			listing, err := tt.change(useCase)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if repo.listings["listing-1"].Status != tt.from {
+					t.Errorf("status changed to %q on rejected transition", repo.listings["listing-1"].Status)
+				}
+				return
+			}
+
+			if listing.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", listing.Status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestListingUseCase_BookmarkNotifications(t *testing.T) {
+	owner := &domain.Actor{UserID: "owner"}
+
+	tests := []struct {
+		name     string
+		change   func(*ListingUseCase) error
+		wantType string
+	}{
+		{
+			name: "price drop",
+			change: func(u *ListingUseCase) error {
+				return u.UpdateListing(owner, &domain.Listing{ID: "listing-1", Title: "House", Price: 900}, nil)
+			},
+			wantType: domain.NotificationPriceDrop,
+		},
+		{
+			name: "price rise",
+			change: func(u *ListingUseCase) error {
+				return u.UpdateListing(owner, &domain.Listing{ID: "listing-1", Title: "House", Price: 1100}, nil)
+			},
+		},
+		{
+			name: "status change",
+			change: func(u *ListingUseCase) error {
+				_, err := u.MarkListingSold(owner, "listing-1")
+				return err
+			},
+			wantType: domain.NotificationStatusChange,
+		},
+		{
+			name: "deleted",
+			change: func(u *ListingUseCase) error {
+				return u.DeleteListing(owner, "listing-1")
+			},
+			wantType: domain.NotificationListingDeleted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeListingRepository(&domain.GetListingDetailsResponse{
+				ID:     "listing-1",
+				UserID: "owner",
+				Title:  "House",
+				Type:   "sale",
+				Price:  1000,
+				Status: domain.ListingStatusPublished,
+			})
+			repo.subscribers = []string{"buyer-1", "buyer-2"}
+			useCase, notifications := newTestListingUseCase(repo, &fakeFileRepository{})
+
+			if err := tt.change(useCase); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tt.wantType == "" {
+				if len(notifications.created) != 0 {
+					t.Errorf("notifications = %v, want none", notifications.created)
+				}
+				return
+			}
+
+			if len(notifications.created) != len(repo.subscribers) {
+				t.Fatalf("notifications = %d, want %d", len(notifications.created), len(repo.subscribers))
+			}
+			for _, n := range notifications.created {
+				if n.Type != tt.wantType {
+					t.Errorf("notification type = %q, want %q", n.Type, tt.wantType)
+				}
+			}
+		})
+	}
+}
+
+func TestListingUseCase_GetListingByID_BookmarkState(t *testing.T) {
+	tests := []struct {
+		name   string
+		viewer *domain.Actor
+		want   bool
+	}{
+		{name: "anonymous"},
+		{name: "bookmarked", viewer: &domain.Actor{UserID: "buyer"}, want: true},
+		{name: "not bookmarked", viewer: &domain.Actor{UserID: "someone-else"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeListingRepository(&domain.GetListingDetailsResponse{
+				ID: "listing-1", UserID: "owner", Status: domain.ListingStatusPublished,
+			})
+			repo.bookmarks = map[string]bool{"buyer/listing-1": true}
+			useCase, _ := newTestListingUseCase(repo, &fakeFileRepository{})
+
+			listing, err := useCase.GetListingByID("listing-1", tt.viewer)
+			if err != nil {
+				t.Fatalf("GetListingByID() error = %v", err)
+			}
+			if listing.IsBookmarked != tt.want {
+				t.Errorf("IsBookmarked = %v, want %v", listing.IsBookmarked, tt.want)
+			}
+		})
+	}
+}
+
+func TestListingUseCase_UpdateListing_StaleVersion(t *testing.T) {
+	repo := newFakeListingRepository(&domain.GetListingDetailsResponse{ID: "listing-1", UserID: "owner", Version: 3})
+	useCase, _ := newTestListingUseCase(repo, &fakeFileRepository{})
+	owner := &domain.Actor{UserID: "owner"}
+
+	stale := 2
+	if err := useCase.UpdateListing(owner, &domain.Listing{ID: "listing-1", Title: "Old tab"}, &stale); err != domain.ErrVersionConflict {
+		t.Errorf("UpdateListing() error = %v, want %v", err, domain.ErrVersionConflict)
+	}
+	if len(repo.updated) != 0 {
+		t.Errorf("updated = %v, want no updates", repo.updated)
+	}
+
+	current := 3
+	if err := useCase.UpdateListing(owner, &domain.Listing{ID: "listing-1", Title: "New tab"}, &current); err != nil {
+		t.Errorf("UpdateListing() error = %v", err)
+	}
+}
+
+func TestListingUseCase_PublicReads_HideNonPublicListings(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  string
+		viewer  *domain.Actor
+		wantErr error
+	}{
+		{name: "published to anyone", status: domain.ListingStatusPublished},
+		{name: "sold to anyone", status: domain.ListingStatusSold},
+		{name: "draft to anonymous", status: domain.ListingStatusDraft, wantErr: domain.ErrListingNotFound},
+		{name: "archived to another user", status: domain.ListingStatusArchived, viewer: &domain.Actor{UserID: "buyer"}, wantErr: domain.ErrListingNotFound},
+		{name: "draft to its owner", status: domain.ListingStatusDraft, viewer: &domain.Actor{UserID: "owner"}},
+		{name: "archived to an admin", status: domain.ListingStatusArchived, viewer: &domain.Actor{UserID: "admin", IsAdmin: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeListingRepository(&domain.GetListingDetailsResponse{ID: "listing-1", UserID: "owner", Status: tt.status})
+			useCase, _ := newTestListingUseCase(repo, &fakeFileRepository{})
+
+			if _, err := useCase.GetListingByID("listing-1", tt.viewer); err != tt.wantErr {
+				t.Errorf("GetListingByID() error = %v, want %v", err, tt.wantErr)
+			}
+			if _, err := useCase.GetPriceHistory("listing-1", tt.viewer); err != tt.wantErr {
+				t.Errorf("GetPriceHistory() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestListingUseCase_GetListings_AvailabilityRange(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		wantErr error
+	}{
+		{name: "no dates"},
+		{name: "both dates", from: "2025-06-01", to: "2025-06-08"},
+		{name: "only from", from: "2025-06-01", wantErr: domain.ErrIncompleteDates},
+		{name: "only to", to: "2025-06-08", wantErr: domain.ErrIncompleteDates},
+		{name: "reversed", from: "2025-06-08", to: "2025-06-01", wantErr: domain.ErrInvalidDateRange},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useCase, _ := newTestListingUseCase(newFakeListingRepository(), &fakeFileRepository{})
+
+			filter := &domain.ListingFilter{AvailableFrom: tt.from, AvailableTo: tt.to}
+			if _, err := useCase.GetListings(filter); err != tt.wantErr {
+				t.Errorf("GetListings() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package usecases
+
+import (
+	"message-server/internal/domain"
+	"message-server/pkg"
+)
+
+type NotificationUseCase struct {
+	notificationRepo domain.NotificationRepository
+	pusher           domain.NotificationPusher
+}
+
+func NewNotificationUseCase(notificationRepo domain.NotificationRepository) *NotificationUseCase {
+	return &NotificationUseCase{notificationRepo: notificationRepo}
+}
+
+// SetPusher registers the real-time channel. It lives in the controller
+// layer, so it is wired after construction.
+func (s *NotificationUseCase) SetPusher(pusher domain.NotificationPusher) {
+	s.pusher = pusher
+}
+
+// Notify stores the notification for every user and pushes it to those who
+// are online. Notifications are a side effect of another change that has
+// already succeeded, so failures are logged rather than returned.
+func (s *NotificationUseCase) Notify(userIDs []string, notification *domain.Notification) {
+	if len(userIDs) == 0 {
+		return
+	}
+
+	created, err := s.notificationRepo.CreateNotifications(userIDs, notification)
+	if err != nil {
+		pkg.Logger.Printf("Failed to create %s notifications: %v", notification.Type, err)
+		return
+	}
+
+	if s.pusher == nil {
+		return
+	}
+	for i := range created {
+		s.pusher.PushNotification(created[i].UserID, &created[i])
+	}
+}
+
+func (s *NotificationUseCase) GetNotifications(userID string, query *domain.CursorQuery) (*domain.GetNotificationsResponse, error) {
+	after, err := decodeCursor(query.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := clampLimit(query.Limit, domain.DefaultNotificationsLimit, domain.MaxNotificationsLimit)
+	return s.notificationRepo.GetNotifications(userID, after, limit)
+}
+
+func (s *NotificationUseCase) MarkNotificationRead(userID, id string) error {
+	return s.notificationRepo.MarkNotificationRead(userID, id)
+}
+
+func (s *NotificationUseCase) MarkAllNotificationsRead(userID string) error {
+	return s.notificationRepo.MarkAllNotificationsRead(userID)
+}
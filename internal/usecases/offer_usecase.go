@@ -0,0 +1,272 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"message-server/internal/domain"
+	"message-server/pkg"
+	"time"
+)
+
+// offerEventTexts is the room message posted for each offer event.
+var offerEventTexts = map[string]string{
+	domain.OfferEventSubmitted: "Offer of %d submitted.",
+	domain.OfferEventCountered: "Counter-offer of %d.",
+	domain.OfferEventAccepted:  "Offer of %d accepted.",
+	domain.OfferEventRejected:  "Offer of %d rejected.",
+	domain.OfferEventWithdrawn: "Offer of %d withdrawn.",
+	domain.OfferEventExpired:   "Offer of %d expired.",
+}
+
+type OfferUseCase struct {
+	offerRepo   domain.OfferRepository
+	listingRepo domain.ListingRepository
+	rooms       *RoomUseCase
+	listings    *ListingUseCase
+	now         func() time.Time
+}
+
+func NewOfferUseCase(
+	offerRepo domain.OfferRepository,
+	listingRepo domain.ListingRepository,
+	rooms *RoomUseCase,
+	listings *ListingUseCase,
+) *OfferUseCase {
+	return &OfferUseCase{
+		offerRepo:   offerRepo,
+		listingRepo: listingRepo,
+		rooms:       rooms,
+		listings:    listings,
+		now:         time.Now,
+	}
+}
+
+// SubmitOffer opens a negotiation on a published listing in the customer's
+// room with the owner. The owner is the first to respond.
+func (s *OfferUseCase) SubmitOffer(customerID, listingID string, req *domain.OfferRequest) (*domain.Offer, error) {
+	listing, err := s.listingRepo.GetListingByID(listingID)
+	if err != nil {
+		return nil, err
+	}
+	if listing.UserID == customerID {
+		return nil, domain.ErrCannotOfferOwn
+	}
+	if listing.Status != domain.ListingStatusPublished {
+		return nil, domain.ErrListingNotOfferable
+	}
+
+	roomID, err := s.rooms.GetOrCreateRoom(listing.ID, listing.UserID, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := s.offerRepo.CreateOffer(&domain.Offer{
+		ListingID:  listing.ID,
+		RoomID:     roomID,
+		CustomerID: customerID,
+		OwnerID:    listing.UserID,
+		Amount:     req.Amount,
+		Conditions: req.Conditions,
+		ExpiresAt:  s.expiresAt(req),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.reloadAndPost(id, domain.OfferEventSubmitted, customerID)
+}
+
+// CounterOffer replaces the terms on the table and hands the turn to the
+// other party.
+func (s *OfferUseCase) CounterOffer(userID, id string, req *domain.OfferRequest) (*domain.Offer, error) {
+	offer, err := s.awaitingOffer(userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.offerRepo.CounterOffer(id, userID, counterparty(offer, userID), req, s.expiresAt(req)); err != nil {
+		return nil, err
+	}
+
+	return s.reloadAndPost(id, domain.OfferEventCountered, userID)
+}
+
+// AcceptOffer closes the deal on the current terms, puts the listing under
+// offer and rejects the other offers on it. The customer may be the one
+// accepting a counter-offer, so the listing changes on the owner's behalf.
+func (s *OfferUseCase) AcceptOffer(userID, id string) (*domain.Offer, error) {
+	offer, err := s.awaitingOffer(userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	rejected, err := s.offerRepo.AcceptOffer(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.listings.NotifyStatusChange(offer.ListingID, domain.ListingStatusUnderOffer)
+
+	accepted, err := s.reloadAndPost(id, domain.OfferEventAccepted, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rejectedID := range rejected {
+		if _, err := s.reloadAndPost(rejectedID, domain.OfferEventRejected, offer.OwnerID); err != nil {
+			pkg.Logger.Printf("Failed to post rejection of offer %s: %v", rejectedID, err)
+		}
+	}
+
+	return accepted, nil
+}
+
+func (s *OfferUseCase) RejectOffer(userID, id string) (*domain.Offer, error) {
+	if _, err := s.awaitingOffer(userID, id); err != nil {
+		return nil, err
+	}
+
+	if err := s.offerRepo.CloseOffer(id, userID, domain.OfferStatusRejected, domain.OfferEventRejected); err != nil {
+		return nil, err
+	}
+
+	return s.reloadAndPost(id, domain.OfferEventRejected, userID)
+}
+
+// WithdrawOffer lets the customer back out whoever's turn it is.
+func (s *OfferUseCase) WithdrawOffer(customerID, id string) (*domain.Offer, error) {
+	offer, err := s.participantOffer(customerID, id)
+	if err != nil {
+		return nil, err
+	}
+	if offer.CustomerID != customerID {
+		return nil, domain.ErrForbidden
+	}
+
+	if err := s.offerRepo.CloseOffer(id, customerID, domain.OfferStatusWithdrawn, domain.OfferEventWithdrawn); err != nil {
+		return nil, err
+	}
+
+	return s.reloadAndPost(id, domain.OfferEventWithdrawn, customerID)
+}
+
+// GetOffer returns the offer with its full history.
+func (s *OfferUseCase) GetOffer(userID, id string) (*domain.Offer, error) {
+	offer, err := s.participantOffer(userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if offer.History, err = s.offerRepo.GetOfferHistory(id); err != nil {
+		return nil, err
+	}
+
+	return offer, nil
+}
+
+func (s *OfferUseCase) GetUserOffers(userID string) ([]domain.Offer, error) {
+	return s.offerRepo.GetUserOffers(userID)
+}
+
+// RunExpiry expires lapsed offers every interval until ctx is done. Only the
+// instance holding the lock runs an expiry pass.
+func (s *OfferUseCase) RunExpiry(ctx context.Context, interval time.Duration, lock domain.JobLock) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			err := runExclusively(lock, "offer_expiry", func() error {
+				return s.ExpireOffers(now)
+			})
+			if err != nil {
+				pkg.Logger.Printf("Offer expiry failed: %v", err)
+			}
+		}
+	}
+}
+
+// ExpireOffers closes every pending offer past its expiry and tells both
+// parties. The expiry is attributed to the party who let it lapse.
+func (s *OfferUseCase) ExpireOffers(now time.Time) error {
+	offers, err := s.offerRepo.ExpireOffers(now)
+	if err != nil {
+		return err
+	}
+
+	for i := range offers {
+		s.postOfferEvent(&offers[i], domain.OfferEventExpired, offers[i].AwaitingUserID)
+	}
+	return nil
+}
+
+// awaitingOffer loads a pending offer that is waiting for the user's
+// response.
+func (s *OfferUseCase) awaitingOffer(userID, id string) (*domain.Offer, error) {
+	offer, err := s.participantOffer(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if offer.Status != domain.OfferStatusPending {
+		return nil, domain.ErrOfferClosed
+	}
+	if !offer.ExpiresAt.After(s.now()) {
+		return nil, domain.ErrOfferExpired
+	}
+	if offer.AwaitingUserID != userID {
+		return nil, domain.ErrNotYourTurn
+	}
+
+	return offer, nil
+}
+
+// participantOffer loads an offer the user made or received. Anyone else
+// gets ErrOfferNotFound.
+func (s *OfferUseCase) participantOffer(userID, id string) (*domain.Offer, error) {
+	offer, err := s.offerRepo.GetOffer(id)
+	if err != nil {
+		return nil, err
+	}
+	if offer.CustomerID != userID && offer.OwnerID != userID {
+		return nil, domain.ErrOfferNotFound
+	}
+
+	return offer, nil
+}
+
+func (s *OfferUseCase) reloadAndPost(id, eventType, actorID string) (*domain.Offer, error) {
+	offer, err := s.offerRepo.GetOffer(id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.postOfferEvent(offer, eventType, actorID)
+	return offer, nil
+}
+
+// postOfferEvent is a side effect of a change that has already been saved,
+// so failures are only logged.
+func (s *OfferUseCase) postOfferEvent(offer *domain.Offer, eventType, actorID string) {
+	text := fmt.Sprintf(offerEventTexts[eventType], offer.Amount)
+	if err := s.rooms.PostOfferEvent(offer, eventType, actorID, text); err != nil {
+		pkg.Logger.Printf("Failed to post offer event to room %s: %v", offer.RoomID, err)
+	}
+}
+
+func (s *OfferUseCase) expiresAt(req *domain.OfferRequest) time.Time {
+	hours := req.ExpiresInHours
+	if hours == 0 {
+		hours = domain.DefaultOfferExpiryHours
+	}
+	return s.now().Add(time.Duration(hours) * time.Hour)
+}
+
+func counterparty(offer *domain.Offer, userID string) string {
+	if userID == offer.OwnerID {
+		return offer.CustomerID
+	}
+	return offer.OwnerID
+}
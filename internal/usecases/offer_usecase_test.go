@@ -0,0 +1,264 @@
+package usecases
+
+import (
+	"errors"
+	"message-server/internal/domain"
+	"testing"
+	"time"
+)
+
+type fakeOfferRepository struct {
+	domain.OfferRepository
+	offers   map[string]*domain.Offer
+	events   []string
+	listings *fakeListingRepository
+}
+
+func (r *fakeOfferRepository) CreateOffer(offer *domain.Offer) (string, error) {
+	for _, existing := range r.offers {
+		if existing.ListingID == offer.ListingID && existing.CustomerID == offer.CustomerID &&
+			existing.Status == domain.OfferStatusPending {
+			return "", domain.ErrOfferAlreadyPending
+		}
+	}
+
+	created := *offer
+	created.ID = "offer-1"
+	created.Status = domain.OfferStatusPending
+	created.AwaitingUserID = offer.OwnerID
+	r.offers[created.ID] = &created
+	r.events = append(r.events, domain.OfferEventSubmitted)
+	return created.ID, nil
+}
+
+func (r *fakeOfferRepository) GetOffer(id string) (*domain.Offer, error) {
+	offer, ok := r.offers[id]
+	if !ok {
+		return nil, domain.ErrOfferNotFound
+	}
+	copied := *offer
+	return &copied, nil
+}
+
+func (r *fakeOfferRepository) CounterOffer(id, actorID, awaitingUserID string, req *domain.OfferRequest, expiresAt time.Time) error {
+	offer := r.offers[id]
+	offer.Amount = req.Amount
+	offer.Conditions = req.Conditions
+	offer.ExpiresAt = expiresAt
+	offer.AwaitingUserID = awaitingUserID
+	r.events = append(r.events, domain.OfferEventCountered)
+	return nil
+}
+
+func (r *fakeOfferRepository) CloseOffer(id, actorID, status, eventType string) error {
+	offer := r.offers[id]
+	if offer.Status != domain.OfferStatusPending {
+		return domain.ErrOfferClosed
+	}
+	offer.Status = status
+	r.events = append(r.events, eventType)
+	return nil
+}
+
+func (r *fakeOfferRepository) AcceptOffer(id, actorID string) ([]string, error) {
+	offer := r.offers[id]
+	listing := r.listings.listings[offer.ListingID]
+	if listing.Status != domain.ListingStatusPublished {
+		return nil, domain.ErrListingNotOfferable
+	}
+	if offer.Status != domain.OfferStatusPending || offer.AwaitingUserID != actorID {
+		return nil, domain.ErrOfferClosed
+	}
+
+	offer.Status = domain.OfferStatusAccepted
+	r.events = append(r.events, domain.OfferEventAccepted)
+	listing.Status = domain.ListingStatusUnderOffer
+
+	var rejected []string
+	for _, other := range r.offers {
+		if other.ListingID == offer.ListingID && other.Status == domain.OfferStatusPending {
+			other.Status = domain.OfferStatusRejected
+			r.events = append(r.events, domain.OfferEventRejected)
+			rejected = append(rejected, other.ID)
+		}
+	}
+	return rejected, nil
+}
+
+type offerFixture struct {
+	useCase  *OfferUseCase
+	offers   *fakeOfferRepository
+	listings *fakeListingRepository
+	rooms    *fakeRoomRepository
+}
+
+func newOfferFixture(status string) *offerFixture {
+	listings := newFakeListingRepository(&domain.GetListingDetailsResponse{
+		ID: "listing-1", UserID: "owner", Title: "Flat", Status: status,
+	})
+	offers := &fakeOfferRepository{offers: map[string]*domain.Offer{}, listings: listings}
+	rooms := &fakeRoomRepository{rooms: map[string]*domain.Room{}}
+
+	roomUseCase := NewRoomUseCase(rooms, &fakeAuthRepository{}, listings)
+	listingUseCase, _ := newTestListingUseCase(listings, &fakeFileRepository{})
+	useCase := NewOfferUseCase(offers, listings, roomUseCase, listingUseCase)
+	useCase.now = func() time.Time { return testNow }
+
+	return &offerFixture{useCase: useCase, offers: offers, listings: listings, rooms: rooms}
+}
+
+func TestOfferUseCase_SubmitOffer(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     string
+		customerID string
+		existing   bool
+		wantErr    error
+	}{
+		{name: "submits an offer", status: domain.ListingStatusPublished, customerID: "customer"},
+		{name: "own listing", status: domain.ListingStatusPublished, customerID: "owner", wantErr: domain.ErrCannotOfferOwn},
+		{name: "already under offer", status: domain.ListingStatusUnderOffer, customerID: "customer", wantErr: domain.ErrListingNotOfferable},
+		{name: "pending offer exists", status: domain.ListingStatusPublished, customerID: "customer", existing: true, wantErr: domain.ErrOfferAlreadyPending},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newOfferFixture(tt.status)
+			if tt.existing {
+				f.offers.offers["offer-0"] = &domain.Offer{
+					ID: "offer-0", ListingID: "listing-1", CustomerID: tt.customerID, Status: domain.OfferStatusPending,
+				}
+			}
+
+			offer, err := f.useCase.SubmitOffer(tt.customerID, "listing-1", &domain.OfferRequest{Amount: 900})
+			if err != tt.wantErr {
+				t.Fatalf("SubmitOffer() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			if offer.AwaitingUserID != "owner" || offer.RoomID != "room-customer" {
+				t.Errorf("offer = %+v, want it awaiting the owner in room-customer", offer)
+			}
+			wantExpiry := testNow.Add(domain.DefaultOfferExpiryHours * time.Hour)
+			if !offer.ExpiresAt.Equal(wantExpiry) {
+				t.Errorf("ExpiresAt = %v, want %v", offer.ExpiresAt, wantExpiry)
+			}
+			if len(f.rooms.messages) != 1 || f.rooms.messages[0] != domain.OfferEventSubmitted+":room-customer" {
+				t.Errorf("room messages = %v, want one offer_submitted", f.rooms.messages)
+			}
+		})
+	}
+}
+
+func TestOfferUseCase_Negotiation(t *testing.T) {
+	f := newOfferFixture(domain.ListingStatusPublished)
+	if _, err := f.useCase.SubmitOffer("customer", "listing-1", &domain.OfferRequest{Amount: 900}); err != nil {
+		t.Fatalf("SubmitOffer() error = %v", err)
+	}
+
+	if _, err := f.useCase.AcceptOffer("customer", "offer-1"); err != domain.ErrNotYourTurn {
+		t.Errorf("customer AcceptOffer() error = %v, want %v", err, domain.ErrNotYourTurn)
+	}
+	if _, err := f.useCase.RejectOffer("stranger", "offer-1"); err != domain.ErrOfferNotFound {
+		t.Errorf("stranger RejectOffer() error = %v, want %v", err, domain.ErrOfferNotFound)
+	}
+
+	countered, err := f.useCase.CounterOffer("owner", "offer-1", &domain.OfferRequest{Amount: 950, ExpiresInHours: 24})
+	if err != nil {
+		t.Fatalf("CounterOffer() error = %v", err)
+	}
+	if countered.Amount != 950 || countered.AwaitingUserID != "customer" {
+		t.Errorf("countered offer = %+v, want 950 awaiting the customer", countered)
+	}
+
+	accepted, err := f.useCase.AcceptOffer("customer", "offer-1")
+	if err != nil {
+		t.Fatalf("AcceptOffer() error = %v", err)
+	}
+	if accepted.Status != domain.OfferStatusAccepted {
+		t.Errorf("Status = %q, want %q", accepted.Status, domain.OfferStatusAccepted)
+	}
+	if status := f.listings.listings["listing-1"].Status; status != domain.ListingStatusUnderOffer {
+		t.Errorf("listing status = %q, want %q", status, domain.ListingStatusUnderOffer)
+	}
+
+	if _, err := f.useCase.WithdrawOffer("customer", "offer-1"); err != domain.ErrOfferClosed {
+		t.Errorf("WithdrawOffer() after accepting error = %v, want %v", err, domain.ErrOfferClosed)
+	}
+
+	wantEvents := []string{domain.OfferEventSubmitted, domain.OfferEventCountered, domain.OfferEventAccepted}
+	if len(f.offers.events) != len(wantEvents) {
+		t.Fatalf("events = %v, want %v", f.offers.events, wantEvents)
+	}
+	for i, event := range wantEvents {
+		if f.offers.events[i] != event {
+			t.Errorf("events[%d] = %q, want %q", i, f.offers.events[i], event)
+		}
+	}
+	if len(f.rooms.messages) != len(wantEvents) {
+		t.Errorf("room messages = %v, want one per event", f.rooms.messages)
+	}
+}
+
+func TestOfferUseCase_ExpiredOffer(t *testing.T) {
+	f := newOfferFixture(domain.ListingStatusPublished)
+	f.offers.offers["offer-1"] = &domain.Offer{
+		ID: "offer-1", ListingID: "listing-1", CustomerID: "customer", OwnerID: "owner",
+		Status: domain.OfferStatusPending, AwaitingUserID: "owner", ExpiresAt: testNow.Add(-time.Minute),
+	}
+
+	_, err := f.useCase.AcceptOffer("owner", "offer-1")
+	if !errors.Is(err, domain.ErrOfferExpired) {
+		t.Errorf("AcceptOffer() error = %v, want %v", err, domain.ErrOfferExpired)
+	}
+	if status := f.listings.listings["listing-1"].Status; status != domain.ListingStatusPublished {
+		t.Errorf("listing status = %q, want it unchanged", status)
+	}
+}
+
+func TestOfferUseCase_AcceptOffer_RejectsOtherOffers(t *testing.T) {
+	f := newOfferFixture(domain.ListingStatusPublished)
+	for _, id := range []string{"offer-1", "offer-2"} {
+		f.offers.offers[id] = &domain.Offer{
+			ID: id, ListingID: "listing-1", RoomID: "room-" + id, CustomerID: "customer-" + id, OwnerID: "owner",
+			Status: domain.OfferStatusPending, AwaitingUserID: "owner", ExpiresAt: testNow.Add(time.Hour),
+		}
+	}
+
+	if _, err := f.useCase.AcceptOffer("owner", "offer-1"); err != nil {
+		t.Fatalf("AcceptOffer() error = %v", err)
+	}
+	if status := f.offers.offers["offer-2"].Status; status != domain.OfferStatusRejected {
+		t.Errorf("other offer status = %q, want %q", status, domain.OfferStatusRejected)
+	}
+	wantMessages := []string{domain.OfferEventAccepted + ":room-offer-1", domain.OfferEventRejected + ":room-offer-2"}
+	if len(f.rooms.messages) != len(wantMessages) {
+		t.Fatalf("room messages = %v, want %v", f.rooms.messages, wantMessages)
+	}
+	for i, message := range wantMessages {
+		if f.rooms.messages[i] != message {
+			t.Errorf("room messages[%d] = %q, want %q", i, f.rooms.messages[i], message)
+		}
+	}
+
+	if _, err := f.useCase.AcceptOffer("owner", "offer-2"); err != domain.ErrOfferClosed {
+		t.Errorf("second AcceptOffer() error = %v, want %v", err, domain.ErrOfferClosed)
+	}
+}
+
+func TestOfferUseCase_AcceptOffer_ListingNoLongerPublished(t *testing.T) {
+	f := newOfferFixture(domain.ListingStatusUnderOffer)
+	f.offers.offers["offer-1"] = &domain.Offer{
+		ID: "offer-1", ListingID: "listing-1", CustomerID: "customer", OwnerID: "owner",
+		Status: domain.OfferStatusPending, AwaitingUserID: "owner", ExpiresAt: testNow.Add(time.Hour),
+	}
+
+	if _, err := f.useCase.AcceptOffer("owner", "offer-1"); err != domain.ErrListingNotOfferable {
+		t.Errorf("AcceptOffer() error = %v, want %v", err, domain.ErrListingNotOfferable)
+	}
+	if status := f.offers.offers["offer-1"].Status; status != domain.OfferStatusPending {
+		t.Errorf("offer status = %q, want it still pending", status)
+	}
+}
@@ -0,0 +1,29 @@
+package usecases
+
+import (
+	"message-server/internal/domain"
+	"message-server/pkg"
+)
+
+func decodeCursor(token string) (*domain.Cursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+
+	createdAt, id, err := pkg.DecodeCursor(token)
+	if err != nil {
+		return nil, domain.ErrInvalidCursor
+	}
+
+	return &domain.Cursor{CreatedAt: createdAt, ID: id}, nil
+}
+
+func clampLimit(limit, defaultLimit, maxLimit int) int {
+	if limit < 1 {
+		return defaultLimit
+	}
+	if limit > maxLimit {
+		return maxLimit
+	}
+	return limit
+}
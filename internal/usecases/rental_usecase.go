@@ -0,0 +1,352 @@
+package usecases
+
+import (
+	"fmt"
+	"math"
+	"message-server/internal/domain"
+	"slices"
+	"time"
+)
+
+type RentalUseCase struct {
+	rentalRepo    domain.RentalRepository
+	listingRepo   domain.ListingRepository
+	notifications *NotificationUseCase
+	now           func() time.Time
+}
+
+func NewRentalUseCase(
+	rentalRepo domain.RentalRepository,
+	listingRepo domain.ListingRepository,
+	notifications *NotificationUseCase,
+) *RentalUseCase {
+	return &RentalUseCase{
+		rentalRepo:    rentalRepo,
+		listingRepo:   listingRepo,
+		notifications: notifications,
+		now:           time.Now,
+	}
+}
+
+// GetCalendar lists every night in the query range with its price and
+// whether it can still be booked. It defaults to DefaultCalendarDays from
+// today.
+func (s *RentalUseCase) GetCalendar(listingID string, viewer *domain.Actor, query *domain.CalendarQuery) (*domain.GetCalendarResponse, error) {
+	listing, err := s.rentalListing(listingID, viewer)
+	if err != nil {
+		return nil, err
+	}
+
+	from := s.today()
+	if query.From != "" {
+		if from, err = time.Parse(domain.DateLayout, query.From); err != nil {
+			return nil, domain.ErrInvalidDateRange
+		}
+	}
+	to := from.AddDate(0, 0, domain.DefaultCalendarDays)
+	if query.To != "" {
+		if to, err = time.Parse(domain.DateLayout, query.To); err != nil {
+			return nil, domain.ErrInvalidDateRange
+		}
+	}
+	if err := checkDateRange(from, to); err != nil {
+		return nil, err
+	}
+
+	period, err := s.rentalRepo.GetRentalPeriod(listingID)
+	if err != nil {
+		return nil, err
+	}
+
+	days, err := s.calendarDays(listing, period, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.GetCalendarResponse{ListingID: listingID, Period: period, Days: days}, nil
+}
+
+func (s *RentalUseCase) SetRentalPeriod(actor *domain.Actor, listingID string, req *domain.RentalSettingsRequest) error {
+	if _, err := s.ownRentalListing(actor, listingID); err != nil {
+		return err
+	}
+
+	return s.rentalRepo.SetRentalPeriod(listingID, req.Period)
+}
+
+// SetCalendarDates blocks nights or overrides their price. It doesn't touch
+// reservations that were already confirmed.
+func (s *RentalUseCase) SetCalendarDates(actor *domain.Actor, listingID string, req *domain.CalendarDatesRequest) error {
+	if _, err := s.ownRentalListing(actor, listingID); err != nil {
+		return err
+	}
+
+	from, to, err := parseDateRange(req.From, req.To)
+	if err != nil {
+		return err
+	}
+
+	return s.rentalRepo.SetCalendarDates(listingID, from, to, req.IsBlocked, req.Price)
+}
+
+// RequestReservation creates a pending reservation priced from the calendar.
+// The nights stay open to others until the owner accepts.
+func (s *RentalUseCase) RequestReservation(customerID, listingID string, req *domain.ReservationRequest) (*domain.Reservation, error) {
+	listing, err := s.rentalListing(listingID, &domain.Actor{UserID: customerID})
+	if err != nil {
+		return nil, err
+	}
+	if listing.UserID == customerID {
+		return nil, domain.ErrCannotReserveOwn
+	}
+	if listing.Status != domain.ListingStatusPublished {
+		return nil, domain.ErrListingNotReservable
+	}
+
+	checkIn, checkOut, err := parseDateRange(req.CheckIn, req.CheckOut)
+	if err != nil {
+		return nil, err
+	}
+	if checkIn.Before(s.today()) {
+		return nil, domain.ErrDatesInPast
+	}
+
+	period, err := s.rentalRepo.GetRentalPeriod(listingID)
+	if err != nil {
+		return nil, err
+	}
+	nights := nightsBetween(checkIn, checkOut)
+	if period == domain.RentalPeriodMonthly && nights < domain.MonthlyRentalNights {
+		return nil, domain.ErrStayTooShort
+	}
+
+	days, err := s.calendarDays(listing, period, checkIn, checkOut)
+	if err != nil {
+		return nil, err
+	}
+
+	total := 0
+	for _, day := range days {
+		if !day.IsAvailable {
+			return nil, domain.ErrDatesUnavailable
+		}
+		total += day.Price
+	}
+
+	id, err := s.rentalRepo.CreateReservation(&domain.Reservation{
+		ListingID:  listingID,
+		CustomerID: customerID,
+		OwnerID:    listing.UserID,
+		CheckIn:    req.CheckIn,
+		CheckOut:   req.CheckOut,
+		TotalPrice: total,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	reservation, err := s.rentalRepo.GetReservation(id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.notifyReservation(reservation.OwnerID, reservation, "New reservation request",
+		fmt.Sprintf("%s asked to stay at %s from %s to %s.", reservation.CustomerName, reservation.ListingTitle,
+			reservation.CheckIn, reservation.CheckOut))
+	return reservation, nil
+}
+
+// AcceptReservation confirms a pending reservation. The exclusion
+// constraint on reservations rejects it if another stay was confirmed for
+// the same nights in the meantime.
+func (s *RentalUseCase) AcceptReservation(ownerID, id string) (*domain.Reservation, error) {
+	return s.respondToReservation(ownerID, id, domain.ReservationStatusConfirmed, "accepted")
+}
+
+func (s *RentalUseCase) DeclineReservation(ownerID, id string) (*domain.Reservation, error) {
+	return s.respondToReservation(ownerID, id, domain.ReservationStatusDeclined, "declined")
+}
+
+// CancelReservation lets the customer withdraw a pending request or cancel a
+// confirmed stay.
+func (s *RentalUseCase) CancelReservation(customerID, id string) (*domain.Reservation, error) {
+	reservation, err := s.participantReservation(customerID, id)
+	if err != nil {
+		return nil, err
+	}
+	if reservation.CustomerID != customerID {
+		return nil, domain.ErrForbidden
+	}
+
+	open := []string{domain.ReservationStatusPending, domain.ReservationStatusConfirmed}
+	if err := s.rentalRepo.UpdateReservationStatus(id, domain.ReservationStatusCancelled, open); err != nil {
+		return nil, err
+	}
+
+	reservation.Status = domain.ReservationStatusCancelled
+	s.notifyReservation(reservation.OwnerID, reservation, "Reservation cancelled",
+		fmt.Sprintf("%s cancelled their stay at %s from %s to %s.", reservation.CustomerName, reservation.ListingTitle,
+			reservation.CheckIn, reservation.CheckOut))
+	return reservation, nil
+}
+
+func (s *RentalUseCase) GetReservation(userID, id string) (*domain.Reservation, error) {
+	return s.participantReservation(userID, id)
+}
+
+func (s *RentalUseCase) GetUserReservations(userID string) ([]domain.Reservation, error) {
+	return s.rentalRepo.GetUserReservations(userID)
+}
+
+func (s *RentalUseCase) respondToReservation(ownerID, id, status, verb string) (*domain.Reservation, error) {
+	reservation, err := s.participantReservation(ownerID, id)
+	if err != nil {
+		return nil, err
+	}
+	if reservation.OwnerID != ownerID {
+		return nil, domain.ErrForbidden
+	}
+
+	pending := []string{domain.ReservationStatusPending}
+	if err := s.rentalRepo.UpdateReservationStatus(id, status, pending); err != nil {
+		return nil, err
+	}
+
+	reservation.Status = status
+	s.notifyReservation(reservation.CustomerID, reservation, "Reservation "+verb,
+		fmt.Sprintf("Your stay at %s from %s to %s was %s.", reservation.ListingTitle,
+			reservation.CheckIn, reservation.CheckOut, verb))
+	return reservation, nil
+}
+
+// calendarDays merges the owner's overrides and the confirmed reservations
+// into one entry per night in [from, to).
+func (s *RentalUseCase) calendarDays(listing *domain.GetListingDetailsResponse, period string, from, to time.Time) ([]domain.CalendarDay, error) {
+	overrides, err := s.rentalRepo.GetCalendarDates(listing.ID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	reservations, err := s.rentalRepo.GetConfirmedReservations(listing.ID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	byDate := make(map[string]domain.CalendarDate, len(overrides))
+	for _, override := range overrides {
+		byDate[override.Date.Format(domain.DateLayout)] = override
+	}
+
+	basePrice := nightlyPrice(listing.Price, period)
+	today := s.today()
+	days := make([]domain.CalendarDay, 0, nightsBetween(from, to))
+	for date := from; date.Before(to); date = date.AddDate(0, 0, 1) {
+		key := date.Format(domain.DateLayout)
+		day := domain.CalendarDay{Date: key, Price: basePrice}
+
+		if override, ok := byDate[key]; ok {
+			day.IsBlocked = override.IsBlocked
+			if override.Price != nil {
+				day.Price = *override.Price
+			}
+		}
+
+		// Dates are YYYY-MM-DD, so they compare correctly as strings.
+		day.IsReserved = slices.ContainsFunc(reservations, func(r domain.Reservation) bool {
+			return r.CheckIn <= key && key < r.CheckOut
+		})
+		day.IsAvailable = !day.IsBlocked && !day.IsReserved && !date.Before(today)
+
+		days = append(days, day)
+	}
+
+	return days, nil
+}
+
+// participantReservation loads a reservation the user made or received.
+// Anyone else gets ErrReservationNotFound.
+func (s *RentalUseCase) participantReservation(userID, id string) (*domain.Reservation, error) {
+	reservation, err := s.rentalRepo.GetReservation(id)
+	if err != nil {
+		return nil, err
+	}
+	if reservation.CustomerID != userID && reservation.OwnerID != userID {
+		return nil, domain.ErrReservationNotFound
+	}
+
+	return reservation, nil
+}
+
+func (s *RentalUseCase) rentalListing(listingID string, viewer *domain.Actor) (*domain.GetListingDetailsResponse, error) {
+	listing, err := visibleListing(s.listingRepo, listingID, viewer)
+	if err != nil {
+		return nil, err
+	}
+	if listing.Type != domain.ListingTypeRent {
+		return nil, domain.ErrNotRentalListing
+	}
+
+	return listing, nil
+}
+
+func (s *RentalUseCase) ownRentalListing(actor *domain.Actor, listingID string) (*domain.GetListingDetailsResponse, error) {
+	listing, err := s.rentalListing(listingID, actor)
+	if err != nil {
+		return nil, err
+	}
+	if !actor.CanModify(listing.UserID) {
+		return nil, domain.ErrForbidden
+	}
+
+	return listing, nil
+}
+
+// notifyReservation is a side effect of a change that has already been
+// saved, like every other notification.
+func (s *RentalUseCase) notifyReservation(userID string, reservation *domain.Reservation, title, body string) {
+	s.notifications.Notify([]string{userID}, &domain.Notification{
+		Type:      domain.NotificationReservation,
+		ListingID: &reservation.ListingID,
+		Title:     title,
+		Body:      body,
+	})
+}
+
+func (s *RentalUseCase) today() time.Time {
+	now := s.now().UTC()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// nightlyPrice spreads a monthly rent over MonthlyRentalNights.
+func nightlyPrice(price int, period string) int {
+	if period != domain.RentalPeriodMonthly {
+		return price
+	}
+	return int(math.Round(float64(price) / domain.MonthlyRentalNights))
+}
+
+func nightsBetween(from, to time.Time) int {
+	return int(to.Sub(from).Hours() / 24)
+}
+
+// parseDateRange parses dates that have already passed validation.
+func parseDateRange(fromValue, toValue string) (time.Time, time.Time, error) {
+	from, err := time.Parse(domain.DateLayout, fromValue)
+	if err != nil {
+		return time.Time{}, time.Time{}, domain.ErrInvalidDateRange
+	}
+
+	to, err := time.Parse(domain.DateLayout, toValue)
+	if err != nil {
+		return time.Time{}, time.Time{}, domain.ErrInvalidDateRange
+	}
+
+	return from, to, checkDateRange(from, to)
+}
+
+func checkDateRange(from, to time.Time) error {
+	if !to.After(from) || nightsBetween(from, to) > domain.MaxCalendarDays {
+		return domain.ErrInvalidDateRange
+	}
+	return nil
+}
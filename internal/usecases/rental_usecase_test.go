@@ -0,0 +1,195 @@
+package usecases
+
+import (
+	"errors"
+	"message-server/internal/domain"
+	"testing"
+	"time"
+)
+
+type fakeRentalRepository struct {
+	domain.RentalRepository
+	period       string
+	dates        []domain.CalendarDate
+	confirmed    []domain.Reservation
+	reservations map[string]*domain.Reservation
+}
+
+func (r *fakeRentalRepository) GetRentalPeriod(listingID string) (string, error) {
+	return r.period, nil
+}
+
+func (r *fakeRentalRepository) GetCalendarDates(listingID string, from, to time.Time) ([]domain.CalendarDate, error) {
+	return r.dates, nil
+}
+
+func (r *fakeRentalRepository) GetConfirmedReservations(listingID string, from, to time.Time) ([]domain.Reservation, error) {
+	return r.confirmed, nil
+}
+
+func (r *fakeRentalRepository) CreateReservation(reservation *domain.Reservation) (string, error) {
+	created := *reservation
+	created.ID = "reservation-1"
+	created.Status = domain.ReservationStatusPending
+	r.reservations[created.ID] = &created
+	return created.ID, nil
+}
+
+func (r *fakeRentalRepository) GetReservation(id string) (*domain.Reservation, error) {
+	reservation, ok := r.reservations[id]
+	if !ok {
+		return nil, domain.ErrReservationNotFound
+	}
+	copied := *reservation
+	return &copied, nil
+}
+
+func (r *fakeRentalRepository) UpdateReservationStatus(id, status string, currentStatuses []string) error {
+	reservation := r.reservations[id]
+	for _, current := range currentStatuses {
+		if reservation.Status == current {
+			reservation.Status = status
+			return nil
+		}
+	}
+	return domain.ErrReservationClosed
+}
+
+func parseTestDate(value string) time.Time {
+	parsed, _ := time.Parse(domain.DateLayout, value)
+	return parsed
+}
+
+func newTestRentalUseCase(repo *fakeRentalRepository, listingType string) (*RentalUseCase, *fakeNotificationRepository) {
+	listings := newFakeListingRepository(&domain.GetListingDetailsResponse{
+		ID: "listing-1", UserID: "owner", Type: listingType, Price: 100, Status: domain.ListingStatusPublished,
+	})
+	notifications := &fakeNotificationRepository{}
+
+	useCase := NewRentalUseCase(repo, listings, NewNotificationUseCase(notifications))
+	useCase.now = func() time.Time { return testNow }
+	return useCase, notifications
+}
+
+func TestRentalUseCase_RequestReservation(t *testing.T) {
+	price := 150
+
+	tests := []struct {
+		name        string
+		listingType string
+		repo        *fakeRentalRepository
+		customerID  string
+		checkIn     string
+		checkOut    string
+		wantTotal   int
+		wantErr     error
+	}{
+		{
+			name: "nightly with a price override", listingType: "rent",
+			repo: &fakeRentalRepository{period: domain.RentalPeriodNightly, dates: []domain.CalendarDate{
+				{Date: parseTestDate("2026-05-03"), Price: &price},
+			}},
+			customerID: "customer", checkIn: "2026-05-02", checkOut: "2026-05-05", wantTotal: 350,
+		},
+		{
+			name: "monthly rent is spread over the nights", listingType: "rent",
+			repo:       &fakeRentalRepository{period: domain.RentalPeriodMonthly},
+			customerID: "customer", checkIn: "2026-05-02", checkOut: "2026-06-01", wantTotal: 90,
+		},
+		{
+			name: "monthly stay too short", listingType: "rent",
+			repo:       &fakeRentalRepository{period: domain.RentalPeriodMonthly},
+			customerID: "customer", checkIn: "2026-05-02", checkOut: "2026-05-09", wantErr: domain.ErrStayTooShort,
+		},
+		{
+			name: "blocked night", listingType: "rent",
+			repo: &fakeRentalRepository{period: domain.RentalPeriodNightly, dates: []domain.CalendarDate{
+				{Date: parseTestDate("2026-05-04"), IsBlocked: true},
+			}},
+			customerID: "customer", checkIn: "2026-05-02", checkOut: "2026-05-05", wantErr: domain.ErrDatesUnavailable,
+		},
+		{
+			name: "overlaps a confirmed stay", listingType: "rent",
+			repo: &fakeRentalRepository{period: domain.RentalPeriodNightly, confirmed: []domain.Reservation{
+				{CheckIn: "2026-04-30", CheckOut: "2026-05-03", Status: domain.ReservationStatusConfirmed},
+			}},
+			customerID: "customer", checkIn: "2026-05-02", checkOut: "2026-05-05", wantErr: domain.ErrDatesUnavailable,
+		},
+		{
+			name: "check-in on a confirmed stay's check-out", listingType: "rent",
+			repo: &fakeRentalRepository{period: domain.RentalPeriodNightly, confirmed: []domain.Reservation{
+				{CheckIn: "2026-04-30", CheckOut: "2026-05-02", Status: domain.ReservationStatusConfirmed},
+			}},
+			customerID: "customer", checkIn: "2026-05-02", checkOut: "2026-05-03", wantTotal: 100,
+		},
+		{
+			name: "past dates", listingType: "rent", repo: &fakeRentalRepository{period: domain.RentalPeriodNightly},
+			customerID: "customer", checkIn: "2026-04-28", checkOut: "2026-05-03", wantErr: domain.ErrDatesInPast,
+		},
+		{
+			name: "check-out before check-in", listingType: "rent", repo: &fakeRentalRepository{period: domain.RentalPeriodNightly},
+			customerID: "customer", checkIn: "2026-05-03", checkOut: "2026-05-03", wantErr: domain.ErrInvalidDateRange,
+		},
+		{
+			name: "listing for sale", listingType: "sale", repo: &fakeRentalRepository{period: domain.RentalPeriodNightly},
+			customerID: "customer", checkIn: "2026-05-02", checkOut: "2026-05-05", wantErr: domain.ErrNotRentalListing,
+		},
+		{
+			name: "own listing", listingType: "rent", repo: &fakeRentalRepository{period: domain.RentalPeriodNightly},
+			customerID: "owner", checkIn: "2026-05-02", checkOut: "2026-05-05", wantErr: domain.ErrCannotReserveOwn,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.repo.reservations = map[string]*domain.Reservation{}
+			useCase, notifications := newTestRentalUseCase(tt.repo, tt.listingType)
+
+			reservation, err := useCase.RequestReservation(tt.customerID, "listing-1",
+				&domain.ReservationRequest{CheckIn: tt.checkIn, CheckOut: tt.checkOut})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RequestReservation() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			if reservation.TotalPrice != tt.wantTotal {
+				t.Errorf("TotalPrice = %d, want %d", reservation.TotalPrice, tt.wantTotal)
+			}
+			if len(notifications.created) != 1 || notifications.created[0].UserID != "owner" {
+				t.Errorf("notified %v, want the owner", notifications.created)
+			}
+		})
+	}
+}
+
+func TestRentalUseCase_RespondToReservation(t *testing.T) {
+	repo := &fakeRentalRepository{reservations: map[string]*domain.Reservation{
+		"reservation-1": {ID: "reservation-1", CustomerID: "customer", OwnerID: "owner", Status: domain.ReservationStatusPending},
+	}}
+	useCase, _ := newTestRentalUseCase(repo, "rent")
+
+	if _, err := useCase.AcceptReservation("customer", "reservation-1"); err != domain.ErrForbidden {
+		t.Errorf("customer AcceptReservation() error = %v, want %v", err, domain.ErrForbidden)
+	}
+	if _, err := useCase.AcceptReservation("stranger", "reservation-1"); err != domain.ErrReservationNotFound {
+		t.Errorf("stranger AcceptReservation() error = %v, want %v", err, domain.ErrReservationNotFound)
+	}
+
+	accepted, err := useCase.AcceptReservation("owner", "reservation-1")
+	if err != nil {
+		t.Fatalf("AcceptReservation() error = %v", err)
+	}
+	if accepted.Status != domain.ReservationStatusConfirmed {
+		t.Errorf("Status = %q, want %q", accepted.Status, domain.ReservationStatusConfirmed)
+	}
+
+	if _, err := useCase.DeclineReservation("owner", "reservation-1"); err != domain.ErrReservationClosed {
+		t.Errorf("DeclineReservation() after accepting error = %v, want %v", err, domain.ErrReservationClosed)
+	}
+
+	if _, err := useCase.CancelReservation("customer", "reservation-1"); err != nil {
+		t.Errorf("CancelReservation() of a confirmed stay error = %v", err)
+	}
+}
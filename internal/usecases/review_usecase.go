@@ -0,0 +1,104 @@
+package usecases
+
+import (
+	"message-server/internal/domain"
+	"time"
+)
+
+type ReviewUseCase struct {
+	reviewRepo domain.ReviewRepository
+	roomRepo   domain.RoomRepository
+	authRepo   domain.AuthRepository
+	now        func() time.Time
+}
+
+func NewReviewUseCase(
+	reviewRepo domain.ReviewRepository,
+	roomRepo domain.RoomRepository,
+	authRepo domain.AuthRepository,
+) *ReviewUseCase {
+	return &ReviewUseCase{
+		reviewRepo: reviewRepo,
+		roomRepo:   roomRepo,
+		authRepo:   authRepo,
+		now:        time.Now,
+	}
+}
+
+// CreateReview rates the listing's owner. Only customers with a room for the
+// listing may review, once the room is MinRoomAgeForReview old or a viewing
+// from it has been completed.
+func (s *ReviewUseCase) CreateReview(customerID, listingID string, req *domain.ReviewRequest) (*domain.Review, error) {
+	room, err := s.roomRepo.GetRoomByListingAndCustomer(listingID, customerID)
+	if err == domain.ErrRoomNotFound {
+		return nil, domain.ErrReviewNotAllowed
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	reviewable, err := s.reviewRepo.IsRoomReviewable(room.RoomID, now.Add(-domain.MinRoomAgeForReview), now)
+	if err != nil {
+		return nil, err
+	}
+	if !reviewable {
+		return nil, domain.ErrReviewNotAllowed
+	}
+
+	id, err := s.reviewRepo.CreateReview(&domain.Review{
+		ListingID: listingID,
+		OwnerID:   room.OwnerID,
+		AuthorID:  customerID,
+		Rating:    req.Rating,
+		Text:      req.Text,
+	}, room.RoomID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.reviewRepo.GetReview(id)
+}
+
+// ReplyToReview posts the owner's one public reply.
+func (s *ReviewUseCase) ReplyToReview(ownerID, id string, req *domain.ReviewReplyRequest) (*domain.Review, error) {
+	review, err := s.reviewRepo.GetReview(id)
+	if err != nil {
+		return nil, err
+	}
+	if review.OwnerID != ownerID {
+		return nil, domain.ErrForbidden
+	}
+	if review.Reply != nil {
+		return nil, domain.ErrAlreadyReplied
+	}
+
+	if err := s.reviewRepo.ReplyToReview(id, req.Text); err != nil {
+		return nil, err
+	}
+
+	return s.reviewRepo.GetReview(id)
+}
+
+func (s *ReviewUseCase) GetListingReviews(listingID string) ([]domain.Review, error) {
+	return s.reviewRepo.GetListingReviews(listingID)
+}
+
+func (s *ReviewUseCase) GetUserReviews(username string) (*domain.GetUserReviewsResponse, error) {
+	user, err := s.authRepo.GetUserByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+
+	rating, err := s.reviewRepo.GetOwnerRating(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	reviews, err := s.reviewRepo.GetOwnerReviews(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.GetUserReviewsResponse{Rating: *rating, Reviews: reviews}, nil
+}
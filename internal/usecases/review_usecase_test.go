@@ -0,0 +1,166 @@
+package usecases
+
+import (
+	"errors"
+	"message-server/internal/domain"
+	"testing"
+	"time"
+)
+
+type fakeReviewRepository struct {
+	domain.ReviewRepository
+	reviewable   bool
+	openedBefore time.Time
+	reviews      map[string]*domain.Review
+}
+
+func (r *fakeReviewRepository) IsRoomReviewable(roomID string, openedBefore, now time.Time) (bool, error) {
+	r.openedBefore = openedBefore
+	return r.reviewable, nil
+}
+
+func (r *fakeReviewRepository) CreateReview(review *domain.Review, roomID string) (string, error) {
+	for _, existing := range r.reviews {
+		if existing.ListingID == review.ListingID && existing.AuthorID == review.AuthorID {
+			return "", domain.ErrAlreadyReviewed
+		}
+	}
+
+	created := *review
+	created.ID = "review-1"
+	r.reviews[created.ID] = &created
+	return created.ID, nil
+}
+
+func (r *fakeReviewRepository) GetReview(id string) (*domain.Review, error) {
+	review, ok := r.reviews[id]
+	if !ok {
+		return nil, domain.ErrReviewNotFound
+	}
+	copied := *review
+	return &copied, nil
+}
+
+func (r *fakeReviewRepository) GetOwnerRating(ownerID string) (*domain.RatingSummary, error) {
+	rating := &domain.RatingSummary{}
+	total := 0
+	for _, review := range r.reviews {
+		if review.OwnerID == ownerID {
+			rating.Count++
+			total += review.Rating
+		}
+	}
+	if rating.Count > 0 {
+		rating.Average = float64(total) / float64(rating.Count)
+	}
+	return rating, nil
+}
+
+func (r *fakeReviewRepository) ReplyToReview(id, text string) error {
+	r.reviews[id].Reply = &text
+	return nil
+}
+
+func newTestReviewUseCase(reviews *fakeReviewRepository) *ReviewUseCase {
+	rooms := &fakeRoomRepository{rooms: map[string]*domain.Room{
+		"listing-1/customer": {RoomID: "room-customer", PropertyID: "listing-1", OwnerID: "owner", CustomerID: "customer"},
+	}}
+
+	useCase := NewReviewUseCase(reviews, rooms, &fakeAuthRepository{})
+	useCase.now = func() time.Time { return testNow }
+	return useCase
+}
+
+func TestReviewUseCase_CreateReview(t *testing.T) {
+	tests := []struct {
+		name       string
+		customerID string
+		reviewable bool
+		existing   bool
+		wantErr    error
+	}{
+		{name: "reviews the owner", customerID: "customer", reviewable: true},
+		{name: "no room with the owner", customerID: "stranger", reviewable: true, wantErr: domain.ErrReviewNotAllowed},
+		{name: "room too recent without a viewing", customerID: "customer", wantErr: domain.ErrReviewNotAllowed},
+		{name: "already reviewed", customerID: "customer", reviewable: true, existing: true, wantErr: domain.ErrAlreadyReviewed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reviews := &fakeReviewRepository{reviewable: tt.reviewable, reviews: map[string]*domain.Review{}}
+			if tt.existing {
+				reviews.reviews["review-0"] = &domain.Review{ID: "review-0", ListingID: "listing-1", AuthorID: tt.customerID}
+			}
+			useCase := newTestReviewUseCase(reviews)
+
+			review, err := useCase.CreateReview(tt.customerID, "listing-1", &domain.ReviewRequest{Rating: 4, Text: "Helpful"})
+			if err != tt.wantErr {
+				t.Fatalf("CreateReview() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			if review.OwnerID != "owner" || review.AuthorID != "customer" {
+				t.Errorf("review = %+v, want the customer reviewing the owner", review)
+			}
+			if want := testNow.Add(-domain.MinRoomAgeForReview); !reviews.openedBefore.Equal(want) {
+				t.Errorf("openedBefore = %v, want %v", reviews.openedBefore, want)
+			}
+		})
+	}
+}
+
+func TestReviewUseCase_ReplyToReview(t *testing.T) {
+	reviews := &fakeReviewRepository{reviews: map[string]*domain.Review{
+		"review-1": {ID: "review-1", OwnerID: "owner", AuthorID: "customer", Rating: 2},
+	}}
+	useCase := newTestReviewUseCase(reviews)
+
+	reply := &domain.ReviewReplyRequest{Text: "Sorry to hear that"}
+	if _, err := useCase.ReplyToReview("customer", "review-1", reply); err != domain.ErrForbidden {
+		t.Errorf("author ReplyToReview() error = %v, want %v", err, domain.ErrForbidden)
+	}
+
+	review, err := useCase.ReplyToReview("owner", "review-1", reply)
+	if err != nil {
+		t.Fatalf("ReplyToReview() error = %v", err)
+	}
+	if review.Reply == nil || *review.Reply != reply.Text {
+		t.Errorf("Reply = %v, want %q", review.Reply, reply.Text)
+	}
+
+	if _, err := useCase.ReplyToReview("owner", "review-1", reply); err != domain.ErrAlreadyReplied {
+		t.Errorf("second ReplyToReview() error = %v, want %v", err, domain.ErrAlreadyReplied)
+	}
+}
+
+type fakeUsernameRepository struct {
+	domain.AuthRepository
+	err error
+}
+
+func (r *fakeUsernameRepository) GetUserByUsername(username string) (*domain.User, error) {
+	return nil, r.err
+}
+
+func TestReviewUseCase_GetUserReviews_LookupErrors(t *testing.T) {
+	dbErr := errors.New("connection refused")
+	tests := []struct {
+		name    string
+		err     error
+		wantErr error
+	}{
+		{name: "unknown username", err: domain.ErrUserNotFound, wantErr: domain.ErrUserNotFound},
+		{name: "database failure", err: dbErr, wantErr: dbErr},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useCase := NewReviewUseCase(&fakeReviewRepository{}, nil, &fakeUsernameRepository{err: tt.err})
+			if _, err := useCase.GetUserReviews("alice"); err != tt.wantErr {
+				t.Errorf("GetUserReviews() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
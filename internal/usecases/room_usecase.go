@@ -3,12 +3,14 @@ package usecases
 import (
 	"fmt"
 	"message-server/internal/domain"
+	"time"
 )
 
 type RoomUseCase struct {
 	roomRepo    domain.RoomRepository
 	authRepo    domain.AuthRepository
 	listingRepo domain.ListingRepository
+	pusher      domain.MessagePusher
 }
 
 func NewRoomUseCase(
@@ -30,7 +32,10 @@ func (s *RoomUseCase) CreateRoom(req *domain.CreateChatRoomRequest) (string, err
 	}
 
 	title := listing.Title
-	image := listing.ImageKeys[0]
+	image := ""
+	if len(listing.ImageKeys) > 0 {
+		image = listing.ImageKeys[0]
+	}
 
 	owner, err := s.authRepo.GetUserByID(req.OwnerID)
 	if err != nil {
@@ -45,6 +50,26 @@ func (s *RoomUseCase) CreateRoom(req *domain.CreateChatRoomRequest) (string, err
 	return s.roomRepo.CreateRoom(req.PropertyID, req.OwnerID, owner.FullName, req.CustomerID, customer.FullName, title, image)
 }
 
+// SetPusher registers the real-time channel used for system messages. It
+// lives in the controller layer, so it is wired after construction.
+func (s *RoomUseCase) SetPusher(pusher domain.MessagePusher) {
+	s.pusher = pusher
+}
+
+// GetOrCreateRoom returns the customer's room for the listing, opening one
+// with the owner if they have not talked yet.
+func (s *RoomUseCase) GetOrCreateRoom(listingID, ownerID, customerID string) (string, error) {
+	room, err := s.roomRepo.GetRoomByListingAndCustomer(listingID, customerID)
+	if err == nil {
+		return room.RoomID, nil
+	}
+	if err != domain.ErrRoomNotFound {
+		return "", err
+	}
+
+	return s.CreateRoom(&domain.CreateChatRoomRequest{PropertyID: listingID, OwnerID: ownerID, CustomerID: customerID})
+}
+
 func (s *RoomUseCase) CheckRoomExists(roomID string) (bool, error) {
 	return s.roomRepo.CheckRoomExists(roomID)
 }
@@ -62,19 +87,118 @@ func (s *RoomUseCase) GetRooms(customerID string) ([]domain.Room, error) {
 	return rooms, nil
 }
 
-func (s *RoomUseCase) SaveMessage(text, senderID, roomID string) error {
+// SaveMessage stores a chat message and returns its ID.
+func (s *RoomUseCase) SaveMessage(text, senderID, roomID string) (string, error) {
 	user, err := s.authRepo.GetUserByID(senderID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get sender info: %w", err)
+	}
+
+	return s.roomRepo.SaveMessage(domain.MessageTypeText, text, senderID, user.FullName, roomID, nil)
+}
+
+// PostSystemMessage stores a message on senderID's behalf and pushes it to
+// both participants, so the sender's other views of the room update too.
+func (s *RoomUseCase) PostSystemMessage(roomID, senderID, receiverID, text string) error {
+	return s.postMessage(&domain.MessageResponse{
+		Type:     domain.MessageTypeSystem,
+		Text:     text,
+		SenderID: senderID,
+		RoomID:   roomID,
+	}, receiverID)
+}
+
+// PostOfferEvent posts an offer event to the offer's room as a message of
+// type eventType. The pushed frame carries the offer as it stands after the
+// event.
+func (s *RoomUseCase) PostOfferEvent(offer *domain.Offer, eventType, senderID, text string) error {
+	receiverID := offer.OwnerID
+	if senderID == offer.OwnerID {
+		receiverID = offer.CustomerID
+	}
+
+	return s.postMessage(&domain.MessageResponse{
+		Type:     eventType,
+		Text:     text,
+		SenderID: senderID,
+		RoomID:   offer.RoomID,
+		Offer:    offer,
+	}, receiverID)
+}
+
+func (s *RoomUseCase) postMessage(message *domain.MessageResponse, receiverID string) error {
+	user, err := s.authRepo.GetUserByID(message.SenderID)
 	if err != nil {
 		return fmt.Errorf("failed to get sender info: %w", err)
 	}
 
-	return s.roomRepo.SaveMessage(text, senderID, user.FullName, roomID)
+	var offerID *string
+	if message.Offer != nil {
+		offerID = &message.Offer.ID
+	}
+	message.ID, err = s.roomRepo.SaveMessage(message.Type, message.Text, message.SenderID, user.FullName, message.RoomID, offerID)
+	if err != nil {
+		return err
+	}
+
+	if s.pusher == nil {
+		return nil
+	}
+	message.Timestamp = time.Now().Unix()
+	s.pusher.PushMessage(message.SenderID, message)
+	s.pusher.PushMessage(receiverID, message)
+	return nil
+}
+
+// MarkRead marks the room's messages up to messageID as read by readerID and
+// pushes a read receipt to both participants when anything changed.
+func (s *RoomUseCase) MarkRead(readerID, roomID, messageID string) (*domain.ReadReceipt, error) {
+	room, err := s.roomRepo.GetRoom(roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	otherID := room.OwnerID
+	switch readerID {
+	case room.OwnerID:
+		otherID = room.CustomerID
+	case room.CustomerID:
+	default:
+		return nil, domain.ErrForbidden
+	}
+
+	readAt, err := s.roomRepo.MarkMessagesRead(roomID, readerID, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	receipt := &domain.ReadReceipt{RoomID: roomID, ReaderID: readerID, MessageID: messageID, ReadAt: readAt}
+	if readAt == nil || s.pusher == nil {
+		return receipt, nil
+	}
+
+	message := &domain.MessageResponse{
+		Type:        domain.MessageTypeReadReceipt,
+		SenderID:    readerID,
+		RoomID:      roomID,
+		Timestamp:   time.Now().Unix(),
+		ReadReceipt: receipt,
+	}
+	s.pusher.PushMessage(otherID, message)
+	s.pusher.PushMessage(readerID, message)
+	return receipt, nil
 }
 
 func (s *RoomUseCase) CheckUserInRoom(userID, roomID string) (bool, error) {
 	return s.roomRepo.CheckUserInRoom(userID, roomID)
 }
 
-func (s *RoomUseCase) GetMessagesForRoom(roomID string) ([]map[string]any, error) {
-	return s.roomRepo.GetMessagesForRoom(roomID)
+func (s *RoomUseCase) GetMessagesForRoom(roomID string, query *domain.CursorQuery) (*domain.GetMessagesResponse, error) {
+	before, err := decodeCursor(query.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := clampLimit(query.Limit, domain.DefaultMessagesLimit, domain.MaxMessagesLimit)
+	return s.roomRepo.GetMessagesForRoom(roomID, before, limit)
 }
@@ -1 +1,107 @@
 package usecases
+
+import (
+	"message-server/internal/domain"
+	"testing"
+	"time"
+)
+
+type fakeReadRoomRepository struct {
+	fakeRoomRepository
+	readAt *time.Time
+	marked []string
+}
+
+func (r *fakeReadRoomRepository) GetRoom(roomID string) (*domain.Room, error) {
+	for _, room := range r.rooms {
+		if room.RoomID == roomID {
+			return room, nil
+		}
+	}
+	return nil, domain.ErrRoomNotFound
+}
+
+func (r *fakeReadRoomRepository) MarkMessagesRead(roomID, readerID, messageID string) (*time.Time, error) {
+	if messageID != "message-1" {
+		return nil, domain.ErrMessageNotFound
+	}
+	r.marked = append(r.marked, readerID+":"+messageID)
+	return r.readAt, nil
+}
+
+func TestRoomUseCase_MarkRead(t *testing.T) {
+	tests := []struct {
+		name       string
+		readerID   string
+		roomID     string
+		messageID  string
+		nothingNew bool
+		wantErr    error
+		wantPushed map[string]int
+	}{
+		{name: "customer reads the owner's messages", readerID: "customer", roomID: "room-customer", messageID: "message-1",
+			wantPushed: map[string]int{"customer": 1, "owner": 1}},
+		{name: "owner reads the customer's messages", readerID: "owner", roomID: "room-customer", messageID: "message-1",
+			wantPushed: map[string]int{"customer": 1, "owner": 1}},
+		{name: "already read", readerID: "customer", roomID: "room-customer", messageID: "message-1", nothingNew: true,
+			wantPushed: map[string]int{}},
+		{name: "not a participant", readerID: "stranger", roomID: "room-customer", messageID: "message-1",
+			wantErr: domain.ErrForbidden, wantPushed: map[string]int{}},
+		{name: "unknown room", readerID: "customer", roomID: "room-missing", messageID: "message-1",
+			wantErr: domain.ErrRoomNotFound, wantPushed: map[string]int{}},
+		{name: "message from another room", readerID: "customer", roomID: "room-customer", messageID: "message-2",
+			wantErr: domain.ErrMessageNotFound, wantPushed: map[string]int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rooms := &fakeReadRoomRepository{fakeRoomRepository: fakeRoomRepository{rooms: map[string]*domain.Room{
+				"listing-1/customer": {RoomID: "room-customer", PropertyID: "listing-1", OwnerID: "owner", CustomerID: "customer"},
+			}}}
+			if !tt.nothingNew {
+				readAt := testNow
+				rooms.readAt = &readAt
+			}
+			pusher := &fakeMessagePusher{pushed: map[string]int{}}
+			useCase := NewRoomUseCase(rooms, &fakeAuthRepository{}, newFakeListingRepository())
+			useCase.SetPusher(pusher)
+
+			receipt, err := useCase.MarkRead(tt.readerID, tt.roomID, tt.messageID)
+			if err != tt.wantErr {
+				t.Fatalf("MarkRead() error = %v, want %v", err, tt.wantErr)
+			}
+			if len(pusher.pushed) != len(tt.wantPushed) {
+				t.Errorf("pushed = %v, want %v", pusher.pushed, tt.wantPushed)
+			}
+			for userID, count := range tt.wantPushed {
+				if pusher.pushed[userID] != count {
+					t.Errorf("pushed[%s] = %d, want %d", userID, pusher.pushed[userID], count)
+				}
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			if receipt.ReaderID != tt.readerID || receipt.MessageID != tt.messageID {
+				t.Errorf("receipt = %+v, want %s reading up to %s", receipt, tt.readerID, tt.messageID)
+			}
+			if (receipt.ReadAt == nil) != tt.nothingNew {
+				t.Errorf("ReadAt = %v, want it set only when messages were marked", receipt.ReadAt)
+			}
+		})
+	}
+}
+
+func TestRoomUseCase_PostSystemMessage_PushesMessageID(t *testing.T) {
+	rooms := &fakeRoomRepository{rooms: map[string]*domain.Room{}}
+	pusher := &fakeMessagePusher{pushed: map[string]int{}}
+	useCase := NewRoomUseCase(rooms, &fakeAuthRepository{}, newFakeListingRepository())
+	useCase.SetPusher(pusher)
+
+	if err := useCase.PostSystemMessage("room-customer", "owner", "customer", "Viewing booked."); err != nil {
+		t.Fatalf("PostSystemMessage() error = %v", err)
+	}
+	if pusher.last == nil || pusher.last.ID != "message-1" {
+		t.Errorf("pushed frame = %+v, want it to carry message-1", pusher.last)
+	}
+}
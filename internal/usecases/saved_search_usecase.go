@@ -0,0 +1,163 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"message-server/internal/domain"
+	"message-server/pkg"
+	"strings"
+	"time"
+)
+
+type SavedSearchUseCase struct {
+	savedSearchRepo domain.SavedSearchRepository
+	listingRepo     domain.ListingRepository
+	notifications   *NotificationUseCase
+}
+
+func NewSavedSearchUseCase(
+	savedSearchRepo domain.SavedSearchRepository,
+	listingRepo domain.ListingRepository,
+	notifications *NotificationUseCase,
+) *SavedSearchUseCase {
+	return &SavedSearchUseCase{
+		savedSearchRepo: savedSearchRepo,
+		listingRepo:     listingRepo,
+		notifications:   notifications,
+	}
+}
+
+// CreateSavedSearch only reports listings published from now on.
+func (s *SavedSearchUseCase) CreateSavedSearch(userID string, req *domain.SavedSearchRequest) (*domain.SavedSearch, error) {
+	if err := validateSavedFilter(&req.Filter); err != nil {
+		return nil, err
+	}
+
+	return s.savedSearchRepo.CreateSavedSearch(userID, req)
+}
+
+func (s *SavedSearchUseCase) GetSavedSearches(userID string) ([]domain.SavedSearch, error) {
+	return s.savedSearchRepo.GetSavedSearches(userID)
+}
+
+func (s *SavedSearchUseCase) GetSavedSearch(userID, id string) (*domain.SavedSearch, error) {
+	return s.savedSearchRepo.GetSavedSearch(userID, id)
+}
+
+func (s *SavedSearchUseCase) UpdateSavedSearch(userID, id string, req *domain.SavedSearchRequest) (*domain.SavedSearch, error) {
+	if err := validateSavedFilter(&req.Filter); err != nil {
+		return nil, err
+	}
+
+	return s.savedSearchRepo.UpdateSavedSearch(userID, id, req)
+}
+
+func (s *SavedSearchUseCase) DeleteSavedSearch(userID, id string) error {
+	return s.savedSearchRepo.DeleteSavedSearch(userID, id)
+}
+
+// RunAlerts checks the saved searches every interval until ctx is done. Only
+// the instance holding the lock runs a check.
+func (s *SavedSearchUseCase) RunAlerts(ctx context.Context, interval time.Duration, lock domain.JobLock) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := runExclusively(lock, "saved_search_alerts", s.CheckSavedSearches)
+			if err != nil {
+				pkg.Logger.Printf("Saved search check failed: %v", err)
+			}
+		}
+	}
+}
+
+// CheckSavedSearches notifies the owner of every due search about listings
+// published since it was last checked. A failing search is logged and retried
+// on the next run without holding up the others. The window ends at the
+// database's time, never the app's, so clock skew can't drop or repeat
+// listings.
+func (s *SavedSearchUseCase) CheckSavedSearches() error {
+	searches, now, err := s.savedSearchRepo.GetDueSavedSearches()
+	if err != nil {
+		return err
+	}
+
+	for i := range searches {
+		if err := s.checkSavedSearch(&searches[i], now); err != nil {
+			pkg.Logger.Printf("Saved search %s check failed: %v", searches[i].ID, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *SavedSearchUseCase) checkSavedSearch(search *domain.SavedSearch, now time.Time) error {
+	filter := search.Filter
+	if filter.BBox != "" {
+		bounds, err := parseBoundingBox(filter.BBox)
+		if err != nil {
+			return err
+		}
+		filter.Bounds = bounds
+	}
+	filter.Statuses = domain.PublicListingStatuses
+	filter.PublishedAfter = &search.LastCheckedAt
+	filter.PublishedBefore = &now
+	filter.Sort = "created_at"
+	filter.Page = 1
+	filter.Limit = domain.SavedSearchMatchLimit
+
+	matches, err := s.listingRepo.GetListings(&filter)
+	if err != nil {
+		return err
+	}
+
+	if matches.Total > 0 {
+		s.notifications.Notify([]string{search.UserID}, newMatchesNotification(search, matches))
+	}
+
+	return s.savedSearchRepo.MarkSavedSearchChecked(search.ID, now)
+}
+
+func newMatchesNotification(search *domain.SavedSearch, matches *domain.GetListingsResponse) *domain.Notification {
+	notification := &domain.Notification{
+		Type:  domain.NotificationNewMatches,
+		Title: fmt.Sprintf("New listings for \"%s\"", search.Name),
+	}
+
+	if matches.Total == 1 {
+		listing := matches.Listings[0]
+		notification.ListingID = &listing.ID
+		notification.Body = fmt.Sprintf("%s matches your saved search.", listing.Title)
+		return notification
+	}
+
+	titles := make([]string, len(matches.Listings))
+	for i, listing := range matches.Listings {
+		titles[i] = listing.Title
+	}
+	notification.Body = fmt.Sprintf("%d new listings match your saved search: %s", matches.Total, strings.Join(titles, ", "))
+	if matches.Total > len(matches.Listings) {
+		notification.Body += " and more."
+	} else {
+		notification.Body += "."
+	}
+	return notification
+}
+
+func validateSavedFilter(filter *domain.ListingFilter) error {
+	if err := checkAvailability(filter); err != nil {
+		return err
+	}
+
+	if filter.BBox == "" {
+		return nil
+	}
+
+	_, err := parseBoundingBox(filter.BBox)
+	return err
+}
@@ -0,0 +1,80 @@
+package usecases
+
+import (
+	"message-server/internal/domain"
+	"testing"
+	"time"
+)
+
+type fakeSavedSearchRepository struct {
+	domain.SavedSearchRepository
+	due     []domain.SavedSearch
+	now     time.Time
+	checked map[string]time.Time
+}
+
+func (r *fakeSavedSearchRepository) GetDueSavedSearches() ([]domain.SavedSearch, time.Time, error) {
+	return r.due, r.now, nil
+}
+
+func (r *fakeSavedSearchRepository) MarkSavedSearchChecked(id string, checkedAt time.Time) error {
+	r.checked[id] = checkedAt
+	return nil
+}
+
+type matchingListingRepository struct {
+	domain.ListingRepository
+	matches map[string][]domain.ListingInfo
+	filters []domain.ListingFilter
+}
+
+func (r *matchingListingRepository) GetListings(filter *domain.ListingFilter) (*domain.GetListingsResponse, error) {
+	r.filters = append(r.filters, *filter)
+	listings := r.matches[filter.Type]
+	return &domain.GetListingsResponse{Listings: listings, Total: len(listings)}, nil
+}
+
+func TestSavedSearchUseCase_CheckSavedSearches(t *testing.T) {
+	lastChecked := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	now := lastChecked.Add(time.Hour)
+
+	searches := &fakeSavedSearchRepository{
+		due: []domain.SavedSearch{
+			{ID: "rentals", UserID: "user-1", Name: "Rentals", Filter: domain.ListingFilter{Type: "rent"}, LastCheckedAt: lastChecked},
+			{ID: "sales", UserID: "user-2", Name: "Sales", Filter: domain.ListingFilter{Type: "sale"}, LastCheckedAt: lastChecked},
+		},
+		now:     now,
+		checked: map[string]time.Time{},
+	}
+	listings := &matchingListingRepository{matches: map[string][]domain.ListingInfo{
+		"rent": {{ID: "listing-1", Title: "Flat"}, {ID: "listing-2", Title: "Loft"}},
+	}}
+	notifications := &fakeNotificationRepository{}
+	useCase := NewSavedSearchUseCase(searches, listings, NewNotificationUseCase(notifications))
+
+	if err := useCase.CheckSavedSearches(); err != nil {
+		t.Fatalf("CheckSavedSearches() error = %v", err)
+	}
+
+	for _, filter := range listings.filters {
+		if !filter.PublishedAfter.Equal(lastChecked) || !filter.PublishedBefore.Equal(now) {
+			t.Errorf("published window = (%v, %v], want (%v, %v]", filter.PublishedAfter, filter.PublishedBefore, lastChecked, now)
+		}
+		if len(filter.Statuses) == 0 {
+			t.Error("saved search matched without restricting to public statuses")
+		}
+	}
+
+	if len(notifications.created) != 1 {
+		t.Fatalf("notifications = %d, want 1", len(notifications.created))
+	}
+	if n := notifications.created[0]; n.UserID != "user-1" || n.Type != domain.NotificationNewMatches {
+		t.Errorf("notification = %+v, want new_matches for user-1", n)
+	}
+
+	for _, id := range []string{"rentals", "sales"} {
+		if !searches.checked[id].Equal(now) {
+			t.Errorf("search %s checked at %v, want %v", id, searches.checked[id], now)
+		}
+	}
+}
@@ -5,17 +5,55 @@ import (
 )
 
 type UserUseCase struct {
-	userRepo domain.UserRepository
-	authRepo domain.AuthRepository
+	userRepo    domain.UserRepository
+	authRepo    domain.AuthRepository
+	listingRepo domain.ListingRepository
+	reviewRepo  domain.ReviewRepository
 }
 
-func NewUserUseCase(userRepo domain.UserRepository, authRepo domain.AuthRepository) *UserUseCase {
+func NewUserUseCase(
+	userRepo domain.UserRepository,
+	authRepo domain.AuthRepository,
+	listingRepo domain.ListingRepository,
+	reviewRepo domain.ReviewRepository,
+) *UserUseCase {
 	return &UserUseCase{
-		userRepo: userRepo,
-		authRepo: authRepo,
+		userRepo:    userRepo,
+		authRepo:    authRepo,
+		listingRepo: listingRepo,
+		reviewRepo:  reviewRepo,
 	}
 }
 
 func (s *UserUseCase) UpdateUserInfo(req *domain.UpdateUserRequest) error {
 	return s.userRepo.UpdateUser(req.FullName, req.AvatarKey, req.UserID)
 }
+
+// GetPublicProfile returns the user's profile with their newest active
+// listings, up to MaxListingsLimit.
+func (s *UserUseCase) GetPublicProfile(username string) (*domain.PublicProfile, error) {
+	profile, err := s.userRepo.GetPublicProfile(username)
+	if err != nil {
+		return nil, err
+	}
+
+	rating, err := s.reviewRepo.GetOwnerRating(profile.ID)
+	if err != nil {
+		return nil, err
+	}
+	profile.Rating = *rating
+
+	listings, err := s.listingRepo.GetListings(&domain.ListingFilter{
+		OwnerID:  profile.ID,
+		Statuses: domain.ActiveListingStatuses,
+		Sort:     "created_at",
+		Page:     1,
+		Limit:    domain.MaxListingsLimit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	profile.ActiveListings = listings.Listings
+
+	return profile, nil
+}
@@ -0,0 +1,75 @@
+package usecases
+
+import (
+	"encoding/json"
+	"message-server/internal/domain"
+	"slices"
+	"strings"
+	"testing"
+)
+
+type fakeUserRepository struct {
+	domain.UserRepository
+	profiles map[string]*domain.PublicProfile
+}
+
+func (r *fakeUserRepository) GetPublicProfile(username string) (*domain.PublicProfile, error) {
+	profile, ok := r.profiles[username]
+	if !ok {
+		return nil, domain.ErrUserNotFound
+	}
+	copied := *profile
+	return &copied, nil
+}
+
+func newTestUserUseCase() (*UserUseCase, *fakeListingRepository) {
+	users := &fakeUserRepository{profiles: map[string]*domain.PublicProfile{
+		"owner": {ID: "owner-id", Username: "owner", FullName: "Owner"},
+	}}
+	listings := newFakeListingRepository(
+		&domain.GetListingDetailsResponse{ID: "listing-1", UserID: "owner-id"},
+		&domain.GetListingDetailsResponse{ID: "listing-2", UserID: "someone-else"},
+	)
+	reviews := &fakeReviewRepository{reviews: map[string]*domain.Review{
+		"review-1": {ID: "review-1", OwnerID: "owner-id", Rating: 5},
+		"review-2": {ID: "review-2", OwnerID: "owner-id", Rating: 4},
+	}}
+
+	return NewUserUseCase(users, &fakeAuthRepository{}, listings, reviews), listings
+}
+
+func TestUserUseCase_GetPublicProfile(t *testing.T) {
+	useCase, listings := newTestUserUseCase()
+
+	profile, err := useCase.GetPublicProfile("owner")
+	if err != nil {
+		t.Fatalf("GetPublicProfile() error = %v", err)
+	}
+
+	if profile.Rating.Count != 2 || profile.Rating.Average != 4.5 {
+		t.Errorf("Rating = %+v, want 2 reviews averaging 4.5", profile.Rating)
+	}
+	if len(profile.ActiveListings) != 1 || profile.ActiveListings[0].ID != "listing-1" {
+		t.Errorf("ActiveListings = %+v, want only listing-1", profile.ActiveListings)
+	}
+
+	filter := listings.filters[0]
+	if filter.OwnerID != "owner-id" || !slices.Equal(filter.Statuses, domain.ActiveListingStatuses) {
+		t.Errorf("filter = %+v, want the owner's active listings", filter)
+	}
+
+	if _, err := useCase.GetPublicProfile("nobody"); err != domain.ErrUserNotFound {
+		t.Errorf("GetPublicProfile() of an unknown user error = %v, want %v", err, domain.ErrUserNotFound)
+	}
+}
+
+func TestUser_NeverSerializesPassword(t *testing.T) {
+	body, err := json.Marshal(&domain.User{ID: "user-1", Password: "$2a$10$hash"})
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+
+	if strings.Contains(string(body), "password") || strings.Contains(string(body), "$2a$10$hash") {
+		t.Errorf("User JSON = %s, want no password", body)
+	}
+}
@@ -0,0 +1,235 @@
+package usecases
+
+import (
+	"fmt"
+	"message-server/internal/domain"
+	"message-server/pkg"
+	"slices"
+	"time"
+)
+
+// viewableStatuses are the listing statuses that accept new bookings.
+var viewableStatuses = []string{domain.ListingStatusPublished, domain.ListingStatusUnderOffer}
+
+type ViewingUseCase struct {
+	viewingRepo domain.ViewingRepository
+	listingRepo domain.ListingRepository
+	rooms       *RoomUseCase
+	now         func() time.Time
+}
+
+func NewViewingUseCase(
+	viewingRepo domain.ViewingRepository,
+	listingRepo domain.ListingRepository,
+	rooms *RoomUseCase,
+) *ViewingUseCase {
+	return &ViewingUseCase{
+		viewingRepo: viewingRepo,
+		listingRepo: listingRepo,
+		rooms:       rooms,
+		now:         time.Now,
+	}
+}
+
+func (s *ViewingUseCase) CreateSlot(actor *domain.Actor, listingID string, req *domain.ViewingSlotRequest) (*domain.ViewingSlot, error) {
+	if _, err := s.authorizeListingOwner(actor, listingID); err != nil {
+		return nil, err
+	}
+
+	if !req.StartsAt.After(s.now()) {
+		return nil, domain.ErrSlotInPast
+	}
+
+	return s.viewingRepo.CreateSlot(listingID, req)
+}
+
+// GetSlots lists the upcoming slots of a listing, booked ones included so
+// clients can show them as taken.
+func (s *ViewingUseCase) GetSlots(listingID string, viewer *domain.Actor) ([]domain.ViewingSlot, error) {
+	if _, err := visibleListing(s.listingRepo, listingID, viewer); err != nil {
+		return nil, err
+	}
+
+	return s.viewingRepo.GetSlots(listingID, s.now())
+}
+
+// DeleteSlot refuses to remove a booked slot; the viewing has to be cancelled
+// first so the customer hears about it.
+func (s *ViewingUseCase) DeleteSlot(actor *domain.Actor, listingID, slotID string) error {
+	if _, err := s.authorizeListingOwner(actor, listingID); err != nil {
+		return err
+	}
+
+	slot, err := s.viewingRepo.GetSlot(slotID)
+	if err != nil {
+		return err
+	}
+	if slot.ListingID != listingID {
+		return domain.ErrSlotNotFound
+	}
+	if slot.IsBooked {
+		return domain.ErrSlotAlreadyBooked
+	}
+
+	return s.viewingRepo.DeleteSlot(slotID)
+}
+
+// BookViewing books the slot for the customer and posts a system message in
+// their room with the owner, opening one if needed.
+func (s *ViewingUseCase) BookViewing(customerID, slotID string) (*domain.Viewing, error) {
+	slot, err := s.bookableSlot(slotID)
+	if err != nil {
+		return nil, err
+	}
+
+	listing, err := s.listingRepo.GetListingByID(slot.ListingID)
+	if err != nil {
+		return nil, err
+	}
+	if listing.UserID == customerID {
+		return nil, domain.ErrCannotBookOwnListing
+	}
+	if !slices.Contains(viewableStatuses, listing.Status) {
+		return nil, domain.ErrViewingUnavailable
+	}
+
+	roomID, err := s.rooms.GetOrCreateRoom(listing.ID, listing.UserID, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := s.viewingRepo.CreateViewing(slot.ID, listing.ID, customerID, listing.UserID, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	viewing, err := s.viewingRepo.GetViewing(id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.postSystemMessage(viewing, customerID, fmt.Sprintf("Viewing booked for %s.", formatSlot(viewing.StartsAt)))
+	return viewing, nil
+}
+
+// RescheduleViewing moves the customer's booking to another slot of the same
+// listing.
+func (s *ViewingUseCase) RescheduleViewing(customerID, id string, req *domain.RescheduleViewingRequest) (*domain.Viewing, error) {
+	viewing, err := s.participantViewing(customerID, id)
+	if err != nil {
+		return nil, err
+	}
+	if viewing.CustomerID != customerID {
+		return nil, domain.ErrForbidden
+	}
+	if viewing.Status != domain.ViewingStatusBooked {
+		return nil, domain.ErrViewingNotActive
+	}
+
+	slot, err := s.bookableSlot(req.SlotID)
+	if err != nil {
+		return nil, err
+	}
+	if slot.ListingID != viewing.ListingID {
+		return nil, domain.ErrSlotNotFound
+	}
+
+	if err := s.viewingRepo.RescheduleViewing(id, slot.ID); err != nil {
+		return nil, err
+	}
+
+	rescheduled, err := s.viewingRepo.GetViewing(id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.postSystemMessage(rescheduled, customerID, fmt.Sprintf("Viewing moved from %s to %s.",
+		formatSlot(viewing.StartsAt), formatSlot(rescheduled.StartsAt)))
+	return rescheduled, nil
+}
+
+// CancelViewing may be called by either the customer or the owner.
+func (s *ViewingUseCase) CancelViewing(userID, id string) (*domain.Viewing, error) {
+	viewing, err := s.participantViewing(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if viewing.Status != domain.ViewingStatusBooked {
+		return nil, domain.ErrViewingNotActive
+	}
+
+	if err := s.viewingRepo.CancelViewing(id); err != nil {
+		return nil, err
+	}
+
+	viewing.Status = domain.ViewingStatusCancelled
+	s.postSystemMessage(viewing, userID, fmt.Sprintf("Viewing on %s was cancelled.", formatSlot(viewing.StartsAt)))
+	return viewing, nil
+}
+
+func (s *ViewingUseCase) GetViewing(userID, id string) (*domain.Viewing, error) {
+	return s.participantViewing(userID, id)
+}
+
+func (s *ViewingUseCase) GetUpcomingViewings(userID string) ([]domain.Viewing, error) {
+	return s.viewingRepo.GetUpcomingViewings(userID, s.now())
+}
+
+func (s *ViewingUseCase) bookableSlot(slotID string) (*domain.ViewingSlot, error) {
+	slot, err := s.viewingRepo.GetSlot(slotID)
+	if err != nil {
+		return nil, err
+	}
+	if !slot.StartsAt.After(s.now()) {
+		return nil, domain.ErrSlotInPast
+	}
+	if slot.IsBooked {
+		return nil, domain.ErrSlotAlreadyBooked
+	}
+
+	return slot, nil
+}
+
+// participantViewing loads a viewing the user is the customer or owner of.
+// Anyone else gets ErrViewingNotFound.
+func (s *ViewingUseCase) participantViewing(userID, id string) (*domain.Viewing, error) {
+	viewing, err := s.viewingRepo.GetViewing(id)
+	if err != nil {
+		return nil, err
+	}
+	if viewing.CustomerID != userID && viewing.OwnerID != userID {
+		return nil, domain.ErrViewingNotFound
+	}
+
+	return viewing, nil
+}
+
+func (s *ViewingUseCase) authorizeListingOwner(actor *domain.Actor, listingID string) (*domain.GetListingDetailsResponse, error) {
+	listing, err := s.listingRepo.GetListingByID(listingID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !actor.CanModify(listing.UserID) {
+		return nil, domain.ErrForbidden
+	}
+
+	return listing, nil
+}
+
+// postSystemMessage tells the other participant in the chat room. The
+// booking change has already been saved, so failures are only logged.
+func (s *ViewingUseCase) postSystemMessage(viewing *domain.Viewing, senderID, text string) {
+	receiverID := viewing.OwnerID
+	if senderID == viewing.OwnerID {
+		receiverID = viewing.CustomerID
+	}
+
+	if err := s.rooms.PostSystemMessage(viewing.RoomID, senderID, receiverID, text); err != nil {
+		pkg.Logger.Printf("Failed to post viewing message to room %s: %v", viewing.RoomID, err)
+	}
+}
+
+func formatSlot(t time.Time) string {
+	return t.UTC().Format("Mon 2 Jan 2006 15:04 UTC")
+}
@@ -0,0 +1,236 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"message-server/internal/domain"
+	"testing"
+	"time"
+)
+
+var testNow = time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+
+type fakeViewingRepository struct {
+	domain.ViewingRepository
+	slots    map[string]*domain.ViewingSlot
+	viewings map[string]*domain.Viewing
+}
+
+func (r *fakeViewingRepository) GetSlot(id string) (*domain.ViewingSlot, error) {
+	slot, ok := r.slots[id]
+	if !ok {
+		return nil, domain.ErrSlotNotFound
+	}
+	copied := *slot
+	return &copied, nil
+}
+
+func (r *fakeViewingRepository) CreateViewing(slotID, listingID, customerID, ownerID, roomID string) (string, error) {
+	if r.slots[slotID].IsBooked {
+		return "", domain.ErrSlotAlreadyBooked
+	}
+	r.slots[slotID].IsBooked = true
+
+	id := "viewing-" + slotID
+	r.viewings[id] = &domain.Viewing{
+		ID: id, SlotID: slotID, ListingID: listingID, CustomerID: customerID, OwnerID: ownerID, RoomID: roomID,
+		StartsAt: r.slots[slotID].StartsAt, Status: domain.ViewingStatusBooked,
+	}
+	return id, nil
+}
+
+func (r *fakeViewingRepository) GetViewing(id string) (*domain.Viewing, error) {
+	viewing, ok := r.viewings[id]
+	if !ok {
+		return nil, domain.ErrViewingNotFound
+	}
+	copied := *viewing
+	return &copied, nil
+}
+
+func (r *fakeViewingRepository) RescheduleViewing(id, slotID string) error {
+	viewing := r.viewings[id]
+	r.slots[viewing.SlotID].IsBooked = false
+	r.slots[slotID].IsBooked = true
+	viewing.SlotID = slotID
+	viewing.StartsAt = r.slots[slotID].StartsAt
+	return nil
+}
+
+func (r *fakeViewingRepository) CancelViewing(id string) error {
+	viewing := r.viewings[id]
+	r.slots[viewing.SlotID].IsBooked = false
+	viewing.Status = domain.ViewingStatusCancelled
+	return nil
+}
+
+type fakeRoomRepository struct {
+	domain.RoomRepository
+	rooms    map[string]*domain.Room
+	messages []string
+}
+
+func (r *fakeRoomRepository) GetRoomByListingAndCustomer(propertyID, customerID string) (*domain.Room, error) {
+	room, ok := r.rooms[propertyID+"/"+customerID]
+	if !ok {
+		return nil, domain.ErrRoomNotFound
+	}
+	return room, nil
+}
+
+func (r *fakeRoomRepository) CreateRoom(propertyID, ownerID, ownerName, customerID, customerName, title, image string) (string, error) {
+	room := &domain.Room{RoomID: "room-" + customerID, PropertyID: propertyID, OwnerID: ownerID, CustomerID: customerID}
+	r.rooms[propertyID+"/"+customerID] = room
+	return room.RoomID, nil
+}
+
+func (r *fakeRoomRepository) SaveMessage(messageType, text, senderID, senderName, roomID string, offerID *string) (string, error) {
+	r.messages = append(r.messages, messageType+":"+roomID)
+	return fmt.Sprintf("message-%d", len(r.messages)), nil
+}
+
+type fakeAuthRepository struct {
+	domain.AuthRepository
+}
+
+func (r *fakeAuthRepository) GetUserByID(id string) (*domain.User, error) {
+	return &domain.User{ID: id, FullName: id}, nil
+}
+
+type fakeMessagePusher struct {
+	pushed map[string]int
+	last   *domain.MessageResponse
+}
+
+func (p *fakeMessagePusher) PushMessage(userID string, message *domain.MessageResponse) bool {
+	p.pushed[userID]++
+	p.last = message
+	return true
+}
+
+type viewingFixture struct {
+	useCase  *ViewingUseCase
+	viewings *fakeViewingRepository
+	rooms    *fakeRoomRepository
+	pusher   *fakeMessagePusher
+}
+
+func newViewingFixture(status string) *viewingFixture {
+	listings := newFakeListingRepository(&domain.GetListingDetailsResponse{
+		ID: "listing-1", UserID: "owner", Status: status, ImageKeys: []string{"image"},
+	})
+	viewings := &fakeViewingRepository{
+		slots: map[string]*domain.ViewingSlot{
+			"past":   {ID: "past", ListingID: "listing-1", StartsAt: testNow.Add(-time.Hour)},
+			"slot-1": {ID: "slot-1", ListingID: "listing-1", StartsAt: testNow.Add(24 * time.Hour)},
+			"slot-2": {ID: "slot-2", ListingID: "listing-1", StartsAt: testNow.Add(48 * time.Hour)},
+		},
+		viewings: map[string]*domain.Viewing{},
+	}
+	rooms := &fakeRoomRepository{rooms: map[string]*domain.Room{}}
+	pusher := &fakeMessagePusher{pushed: map[string]int{}}
+
+	roomUseCase := NewRoomUseCase(rooms, &fakeAuthRepository{}, listings)
+	roomUseCase.SetPusher(pusher)
+	useCase := NewViewingUseCase(viewings, listings, roomUseCase)
+	useCase.now = func() time.Time { return testNow }
+
+	return &viewingFixture{useCase: useCase, viewings: viewings, rooms: rooms, pusher: pusher}
+}
+
+func TestViewingUseCase_BookViewing(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     string
+		customerID string
+		slotID     string
+		wantErr    error
+	}{
+		{name: "books a future slot", status: domain.ListingStatusPublished, customerID: "customer", slotID: "slot-1"},
+		{name: "under offer", status: domain.ListingStatusUnderOffer, customerID: "customer", slotID: "slot-1"},
+		{name: "past slot", status: domain.ListingStatusPublished, customerID: "customer", slotID: "past", wantErr: domain.ErrSlotInPast},
+		{name: "own listing", status: domain.ListingStatusPublished, customerID: "owner", slotID: "slot-1", wantErr: domain.ErrCannotBookOwnListing},
+		{name: "draft listing", status: domain.ListingStatusDraft, customerID: "customer", slotID: "slot-1", wantErr: domain.ErrViewingUnavailable},
+		{name: "unknown slot", status: domain.ListingStatusPublished, customerID: "customer", slotID: "missing", wantErr: domain.ErrSlotNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fixture := newViewingFixture(tt.status)
+
+			viewing, err := fixture.useCase.BookViewing(tt.customerID, tt.slotID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("BookViewing() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if len(fixture.rooms.messages) != 0 {
+					t.Errorf("messages = %v, want none", fixture.rooms.messages)
+				}
+				return
+			}
+
+			if viewing.RoomID != "room-customer" {
+				t.Errorf("RoomID = %q, want the room opened for the customer", viewing.RoomID)
+			}
+			if len(fixture.rooms.messages) != 1 || fixture.rooms.messages[0] != "system:room-customer" {
+				t.Errorf("messages = %v, want one system message", fixture.rooms.messages)
+			}
+			if fixture.pusher.pushed["owner"] != 1 || fixture.pusher.pushed["customer"] != 1 {
+				t.Errorf("pushed = %v, want both participants", fixture.pusher.pushed)
+			}
+		})
+	}
+}
+
+func TestViewingUseCase_DoubleBooking(t *testing.T) {
+	fixture := newViewingFixture(domain.ListingStatusPublished)
+
+	if _, err := fixture.useCase.BookViewing("customer", "slot-1"); err != nil {
+		t.Fatalf("BookViewing() error = %v", err)
+	}
+	if _, err := fixture.useCase.BookViewing("someone-else", "slot-1"); err != domain.ErrSlotAlreadyBooked {
+		t.Errorf("second BookViewing() error = %v, want %v", err, domain.ErrSlotAlreadyBooked)
+	}
+}
+
+func TestViewingUseCase_RescheduleAndCancel(t *testing.T) {
+	fixture := newViewingFixture(domain.ListingStatusPublished)
+
+	viewing, err := fixture.useCase.BookViewing("customer", "slot-1")
+	if err != nil {
+		t.Fatalf("BookViewing() error = %v", err)
+	}
+
+	request := &domain.RescheduleViewingRequest{SlotID: "slot-2"}
+	if _, err := fixture.useCase.RescheduleViewing("owner", viewing.ID, request); err != domain.ErrForbidden {
+		t.Errorf("owner RescheduleViewing() error = %v, want %v", err, domain.ErrForbidden)
+	}
+
+	rescheduled, err := fixture.useCase.RescheduleViewing("customer", viewing.ID, request)
+	if err != nil {
+		t.Fatalf("RescheduleViewing() error = %v", err)
+	}
+	if rescheduled.SlotID != "slot-2" || fixture.viewings.slots["slot-1"].IsBooked {
+		t.Errorf("viewing still holds slot-1 after rescheduling")
+	}
+
+	if _, err := fixture.useCase.CancelViewing("stranger", viewing.ID); err != domain.ErrViewingNotFound {
+		t.Errorf("stranger CancelViewing() error = %v, want %v", err, domain.ErrViewingNotFound)
+	}
+
+	cancelled, err := fixture.useCase.CancelViewing("owner", viewing.ID)
+	if err != nil {
+		t.Fatalf("CancelViewing() error = %v", err)
+	}
+	if cancelled.Status != domain.ViewingStatusCancelled {
+		t.Errorf("Status = %q, want %q", cancelled.Status, domain.ViewingStatusCancelled)
+	}
+
+	if _, err := fixture.useCase.CancelViewing("customer", viewing.ID); err != domain.ErrViewingNotActive {
+		t.Errorf("second CancelViewing() error = %v, want %v", err, domain.ErrViewingNotActive)
+	}
+
+	if len(fixture.rooms.messages) != 3 {
+		t.Errorf("messages = %v, want one per booking change", fixture.rooms.messages)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"message-server/internal/repository"
 	"message-server/internal/usecases"
 	"os"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
@@ -43,13 +44,47 @@ func main() {
 		Bucket:    os.Getenv("R2_BUCKET_NAME"),
 	})
 	userRepository := repository.NewUserRepository(pool)
+	amenityRepository := repository.NewAmenityRepository(pool)
+	notificationRepository := repository.NewNotificationRepository(pool)
+	savedSearchRepository := repository.NewSavedSearchRepository(pool)
+	collectionRepository := repository.NewCollectionRepository(pool)
+	viewingRepository := repository.NewViewingRepository(pool)
+	calendarRepository := repository.NewCalendarRepository(pool)
+	rentalRepository := repository.NewRentalRepository(pool)
+	offerRepository := repository.NewOfferRepository(pool)
+	reviewRepository := repository.NewReviewRepository(pool)
+
+	// Instances behind a load balancer must share the Postgres broker, or
+	// users on different instances can't reach each other live.
+	broker := repository.NewMemoryBroker()
+	if os.Getenv("CHAT_BROKER") == "postgres" {
+		broker = repository.NewPostgresBroker(pool)
+	}
 
 	roomUseCase := usecases.NewRoomUseCase(roomRepository, authRepository, listingRepository)
 	authUseCase := usecases.NewAuthUseCase(authRepository)
-	listingUseCase := usecases.NewListingUseCase(listingRepository, fileRepository)
+	notificationUseCase := usecases.NewNotificationUseCase(notificationRepository)
+	listingUseCase := usecases.NewListingUseCase(listingRepository, fileRepository, notificationUseCase)
 	fileUseCase := usecases.NewFileUseCase(fileRepository)
-	userUseCase := usecases.NewUserUseCase(userRepository, authRepository)
+	userUseCase := usecases.NewUserUseCase(userRepository, authRepository, listingRepository, reviewRepository)
+	amenityUseCase := usecases.NewAmenityUseCase(amenityRepository)
+	savedSearchUseCase := usecases.NewSavedSearchUseCase(savedSearchRepository, listingRepository, notificationUseCase)
+	viewingUseCase := usecases.NewViewingUseCase(viewingRepository, listingRepository, roomUseCase)
+	calendarUseCase := usecases.NewCalendarUseCase(calendarRepository, viewingUseCase)
+	rentalUseCase := usecases.NewRentalUseCase(rentalRepository, listingRepository, notificationUseCase)
+	offerUseCase := usecases.NewOfferUseCase(offerRepository, listingRepository, roomUseCase, listingUseCase)
+	reviewUseCase := usecases.NewReviewUseCase(reviewRepository, roomRepository, authRepository)
+
+	shareSecret := os.Getenv("SHARE_LINK_SECRET")
+	if shareSecret == "" {
+		shareSecret = os.Getenv("JWT_SECRET")
+	}
+	collectionUseCase := usecases.NewCollectionUseCase(collectionRepository, authRepository, []byte(shareSecret))
+
+	jobLock := repository.NewJobLock(pool)
+	go savedSearchUseCase.RunAlerts(context.Background(), time.Minute, jobLock)
+	go offerUseCase.RunExpiry(context.Background(), time.Minute, jobLock)
 
-	router := router.NewRouter(roomUseCase, authUseCase, listingUseCase, fileUseCase, userUseCase)
+	router := router.NewRouter(roomUseCase, authUseCase, listingUseCase, fileUseCase, userUseCase, amenityUseCase, notificationUseCase, savedSearchUseCase, collectionUseCase, viewingUseCase, calendarUseCase, rentalUseCase, offerUseCase, reviewUseCase, broker)
 	router.Run(":" + port)
 }
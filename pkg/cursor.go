@@ -0,0 +1,37 @@
+package pkg
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// EncodeCursor packs a (created_at, id) keyset position into an opaque,
+// URL-safe token.
+func EncodeCursor(createdAt time.Time, id string) string {
+	raw := createdAt.UTC().Format(time.RFC3339Nano) + "|" + id
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor is the inverse of EncodeCursor.
+func DecodeCursor(cursor string) (time.Time, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", errInvalidCursor
+	}
+
+	createdAtPart, id, found := strings.Cut(string(raw), "|")
+	if !found || id == "" {
+		return time.Time{}, "", errInvalidCursor
+	}
+
+	createdAt, err := time.Parse(time.RFC3339Nano, createdAtPart)
+	if err != nil {
+		return time.Time{}, "", errInvalidCursor
+	}
+
+	return createdAt, id, nil
+}
@@ -0,0 +1,82 @@
+package pkg
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const icalTimeFormat = "20060102T150405Z"
+
+type ICalEvent struct {
+	UID         string
+	Start       time.Time
+	End         time.Time
+	Summary     string
+	Location    string
+	Description string
+	// Cancelled events stay in the calendar so subscribers drop them.
+	Cancelled bool
+}
+
+// BuildICalendar renders an RFC 5545 VCALENDAR with one VEVENT per event.
+// stamp is used as DTSTAMP for every event.
+func BuildICalendar(name string, events []ICalEvent, stamp time.Time) []byte {
+	var b strings.Builder
+	writeICalLine(&b, "BEGIN:VCALENDAR")
+	writeICalLine(&b, "VERSION:2.0")
+	writeICalLine(&b, "PRODID:-//message-server//viewings//EN")
+	writeICalLine(&b, "CALSCALE:GREGORIAN")
+	writeICalLine(&b, "METHOD:PUBLISH")
+	writeICalLine(&b, "X-WR-CALNAME:"+escapeICalText(name))
+
+	for _, event := range events {
+		status := "CONFIRMED"
+		if event.Cancelled {
+			status = "CANCELLED"
+		}
+
+		writeICalLine(&b, "BEGIN:VEVENT")
+		writeICalLine(&b, "UID:"+escapeICalText(event.UID))
+		writeICalLine(&b, "DTSTAMP:"+stamp.UTC().Format(icalTimeFormat))
+		writeICalLine(&b, "DTSTART:"+event.Start.UTC().Format(icalTimeFormat))
+		writeICalLine(&b, "DTEND:"+event.End.UTC().Format(icalTimeFormat))
+		writeICalLine(&b, "SUMMARY:"+escapeICalText(event.Summary))
+		if event.Location != "" {
+			writeICalLine(&b, "LOCATION:"+escapeICalText(event.Location))
+		}
+		if event.Description != "" {
+			writeICalLine(&b, "DESCRIPTION:"+escapeICalText(event.Description))
+		}
+		writeICalLine(&b, "STATUS:"+status)
+		writeICalLine(&b, "END:VEVENT")
+	}
+
+	writeICalLine(&b, "END:VCALENDAR")
+	return []byte(b.String())
+}
+
+var icalTextEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+func escapeICalText(text string) string {
+	return icalTextEscaper.Replace(text)
+}
+
+// writeICalLine ends the line with CRLF and folds it so no line is longer
+// than 75 octets, without splitting a UTF-8 character.
+func writeICalLine(b *strings.Builder, line string) {
+	const maxOctets = 75
+
+	width := 0
+	for _, r := range line {
+		size := utf8.RuneLen(r)
+		if width+size > maxOctets {
+			b.WriteString("\r\n ")
+			// The leading space of a continuation line counts towards it.
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	b.WriteString("\r\n")
+}
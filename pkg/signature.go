@@ -0,0 +1,39 @@
+package pkg
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+var errInvalidSignature = errors.New("invalid signature")
+
+// SignToken returns payload and its HMAC-SHA256 signature as an opaque,
+// URL-safe token. The payload is encoded, not encrypted.
+func SignToken(payload string, secret []byte) string {
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(payload))
+	return encoded + "." + signature(encoded, secret)
+}
+
+// VerifyToken checks a token produced by SignToken and returns its payload.
+func VerifyToken(token string, secret []byte) (string, error) {
+	encoded, sig, found := strings.Cut(token, ".")
+	if !found || !hmac.Equal([]byte(sig), []byte(signature(encoded, secret))) {
+		return "", errInvalidSignature
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", errInvalidSignature
+	}
+
+	return string(payload), nil
+}
+
+func signature(encoded string, secret []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
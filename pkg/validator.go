@@ -2,6 +2,8 @@ package pkg
 
 import (
 	"fmt"
+	"reflect"
+	"strings"
 
 	"github.com/go-playground/validator/v10"
 )
@@ -13,18 +15,70 @@ func ValidateStruct(s any) []string {
 	if err != nil {
 		var errorMessages []string
 		for _, fieldError := range err.(validator.ValidationErrors) {
-			switch fieldError.Tag() {
-			case "required":
-				errorMessages = append(errorMessages, fmt.Sprintf("%s field is required.", fieldError.Field()))
-			case "min":
-				errorMessages = append(errorMessages, fmt.Sprintf("%s field must be at least %s characters long.", fieldError.Field(), fieldError.Param()))
-			case "max":
-				errorMessages = append(errorMessages, fmt.Sprintf("%s field must be at most %s characters long.", fieldError.Field(), fieldError.Param()))
-			case "oneof":
-				errorMessages = append(errorMessages, fmt.Sprintf("%s field must be one of: %s", fieldError.Field(), fieldError.Param()))
-			}
+			errorMessages = append(errorMessages, fieldErrorMessage(fieldError, fieldError.Field()))
 		}
 		return errorMessages
 	}
 	return nil
 }
+
+// ValidateFields is like ValidateStruct but keys each message by the field's
+// JSON name, so clients can show the error next to the right input.
+func ValidateFields(s any) map[string]string {
+	err := validate.Struct(s)
+	if err == nil {
+		return nil
+	}
+
+	t := reflect.TypeOf(s)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	fieldErrors := map[string]string{}
+	for _, fieldError := range err.(validator.ValidationErrors) {
+		name := jsonFieldName(t, fieldError.StructField())
+		fieldErrors[name] = fieldErrorMessage(fieldError, name)
+	}
+	return fieldErrors
+}
+
+func jsonFieldName(t reflect.Type, structField string) string {
+	field, ok := t.FieldByName(structField)
+	if !ok {
+		return structField
+	}
+
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return structField
+	}
+	return name
+}
+
+func fieldErrorMessage(fieldError validator.FieldError, name string) string {
+	switch fieldError.Tag() {
+	case "required":
+		return fmt.Sprintf("%s field is required.", name)
+	case "min":
+		return fmt.Sprintf("%s field must be at least %s characters long.", name, fieldError.Param())
+	case "max":
+		return fmt.Sprintf("%s field must be at most %s characters long.", name, fieldError.Param())
+	case "gte":
+		return fmt.Sprintf("%s field must be greater than or equal to %s.", name, fieldError.Param())
+	case "lte":
+		return fmt.Sprintf("%s field must be less than or equal to %s.", name, fieldError.Param())
+	case "oneof":
+		return fmt.Sprintf("%s field must be one of: %s", name, fieldError.Param())
+	case "gt":
+		return fmt.Sprintf("%s field must be greater than %s.", name, fieldError.Param())
+	case "gtfield":
+		return fmt.Sprintf("%s field must be after %s.", name, fieldError.Param())
+	case "datetime":
+		return fmt.Sprintf("%s field must be a date formatted as YYYY-MM-DD.", name)
+	case "required_with":
+		return fmt.Sprintf("%s field is required when %s is set.", name, fieldError.Param())
+	default:
+		return fmt.Sprintf("%s field is invalid.", name)
+	}
+}